@@ -11,6 +11,10 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	Auth     AuthConfig
+	Storage  StorageConfig
+	Mail     MailConfig
+	Webhook  WebhookConfig
+	Overdue  OverdueConfig
 }
 
 // настройки сервера
@@ -18,6 +22,10 @@ type ServerConfig struct {
 	Port         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// адрес веб-интерфейса для ссылок в письмах
+	PublicURL string
+	// включение отладочных маршрутов /debug (только для разработки)
+	DebugRoutes bool
 }
 
 // настройки базы данных
@@ -32,8 +40,50 @@ type DatabaseConfig struct {
 
 // настройки аутентификации
 type AuthConfig struct {
-	JWTSecret        string
-	JWTExpirationHrs int
+	JWTSecret          string
+	AccessTokenMinutes int
+	RefreshTokenDays   int
+	// срок действия токена сброса пароля
+	PasswordResetMinutes int
+}
+
+// настройки хранилища файлов
+type StorageConfig struct {
+	Path            string
+	MaxUploadSizeMB int
+}
+
+// настройки отправки почты
+type MailConfig struct {
+	Driver       string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+	// ограничение времени соединения и обмена с SMTP-сервером
+	SMTPTimeoutSeconds int
+	// период опроса очереди писем и число попыток до перевода письма в dead
+	OutboxPollSeconds int
+	OutboxMaxAttempts int
+}
+
+// настройки доставки вебхуков
+type WebhookConfig struct {
+	PollSeconds    int
+	MaxAttempts    int
+	TimeoutSeconds int
+	// разрешить адреса во внутренней сети (только для разработки)
+	AllowPrivateTargets bool
+}
+
+// настройки контроля сроков устранения дефектов
+type OverdueConfig struct {
+	CheckIntervalMinutes int
+	// за сколько часов до срока исполнителю отправляется напоминание
+	RemindBeforeHours int
+	// через сколько часов после напоминания о просрочке уведомляется менеджер проекта
+	EscalationGraceHours int
 }
 
 // получение конфигурации приложения
@@ -43,6 +93,8 @@ func GetConfig() *Config {
 			Port:         getEnv("SERVER_PORT", "8080"),
 			ReadTimeout:  time.Duration(getEnvAsInt("SERVER_READ_TIMEOUT", 10)) * time.Second,
 			WriteTimeout: time.Duration(getEnvAsInt("SERVER_WRITE_TIMEOUT", 10)) * time.Second,
+			PublicURL:    getEnv("PUBLIC_URL", "http://localhost:5173"),
+			DebugRoutes:  getEnv("ENABLE_DEBUG_ROUTES", "false") == "true",
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -53,8 +105,36 @@ func GetConfig() *Config {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		Auth: AuthConfig{
-			JWTSecret:        getEnv("JWT_SECRET", "your_secret_key"),
-			JWTExpirationHrs: getEnvAsInt("JWT_EXPIRATION_HRS", 24),
+			JWTSecret:            getEnv("JWT_SECRET", "your_secret_key"),
+			AccessTokenMinutes:   getEnvAsInt("JWT_ACCESS_TTL_MINUTES", 15),
+			RefreshTokenDays:     getEnvAsInt("JWT_REFRESH_TTL_DAYS", 30),
+			PasswordResetMinutes: getEnvAsInt("PASSWORD_RESET_TTL_MINUTES", 60),
+		},
+		Storage: StorageConfig{
+			Path:            getEnv("STORAGE_PATH", "uploads"),
+			MaxUploadSizeMB: getEnvAsInt("MAX_UPLOAD_SIZE_MB", 20),
+		},
+		Mail: MailConfig{
+			Driver:             getEnv("MAIL_DRIVER", "log"),
+			SMTPHost:           getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:           getEnv("SMTP_PORT", "1025"),
+			SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+			From:               getEnv("MAIL_FROM", "noreply@systemcontrol.local"),
+			SMTPTimeoutSeconds: getEnvAsPositiveInt("SMTP_TIMEOUT_SECONDS", 30),
+			OutboxPollSeconds:  getEnvAsPositiveInt("MAIL_OUTBOX_POLL_SECONDS", 5),
+			OutboxMaxAttempts:  getEnvAsPositiveInt("MAIL_OUTBOX_MAX_ATTEMPTS", 8),
+		},
+		Webhook: WebhookConfig{
+			PollSeconds:         getEnvAsPositiveInt("WEBHOOK_POLL_SECONDS", 5),
+			MaxAttempts:         getEnvAsPositiveInt("WEBHOOK_MAX_ATTEMPTS", 10),
+			TimeoutSeconds:      getEnvAsPositiveInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+			AllowPrivateTargets: getEnv("WEBHOOK_ALLOW_PRIVATE_TARGETS", "false") == "true",
+		},
+		Overdue: OverdueConfig{
+			CheckIntervalMinutes: getEnvAsPositiveInt("OVERDUE_CHECK_INTERVAL_MINUTES", 15),
+			RemindBeforeHours:    getEnvAsInt("OVERDUE_REMIND_BEFORE_HOURS", 24),
+			EscalationGraceHours: getEnvAsInt("OVERDUE_ESCALATION_GRACE_HOURS", 48),
 		},
 	}
 }
@@ -81,3 +161,13 @@ func getEnvAsInt(key string, defaultValue int) int {
 
 	return value
 }
+
+// целое значение, которое должно быть больше нуля (интервалы, таймауты, число попыток);
+// при нуле или отрицательном значении используется значение по умолчанию
+func getEnvAsPositiveInt(key string, defaultValue int) int {
+	value := getEnvAsInt(key, defaultValue)
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
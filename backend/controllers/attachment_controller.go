@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"systemControl_proj/config"
+	"systemControl_proj/database"
+	"systemControl_proj/models"
+	"systemControl_proj/storage"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/image/draw"
+	"gorm.io/gorm"
+)
+
+// максимальный размер стороны миниатюры в пикселях
+const thumbnailSize = 320
+
+// максимальное число пикселей изображения, для которого создается миниатюра:
+// размеры читаются из заголовка до декодирования, иначе небольшой файл
+// с заявленными огромными размерами занял бы гигабайты памяти
+const maxThumbnailSourcePixels = 50_000_000
+
+// контроллер запросов связанных с вложениями к дефектам
+type AttachmentController struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+	Config  *config.Config
+}
+
+// создание нового экземпляра контроллера вложений
+func NewAttachmentController(config *config.Config) *AttachmentController {
+	return &AttachmentController{
+		DB:      database.DB,
+		Storage: storage.Files,
+		Config:  config,
+	}
+}
+
+// загрузка файла и создание вложения к дефекту
+func (ac *AttachmentController) UploadAttachment(c *gin.Context) {
+	// Получение ID пользователя из контекста
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "пользователь не авторизован"})
+		return
+	}
+
+	// Проверка существования дефекта
+	var defect models.Defect
+	if result := ac.DB.First(&defect, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	// загружать вложения могут те же роли в проекте, что и изменять дефекты
+	if _, ok := requireProjectRole(c, ac.DB, defect.ProjectID, defectEditorRoles...); !ok {
+		return
+	}
+
+	maxSize := int64(ac.Config.Storage.MaxUploadSizeMB) << 20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("размер файла превышает %d МБ", ac.Config.Storage.MaxUploadSizeMB)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "файл не передан (ожидается поле file)"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ошибка чтения файла"})
+		return
+	}
+	defer file.Close()
+
+	// Определение MIME-типа по содержимому файла
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ошибка чтения файла"})
+		return
+	}
+	head = head[:n]
+	mimeType := http.DetectContentType(head)
+
+	key, err := newStorageKey(fmt.Sprintf("defects/%d", defect.ID), filepath.Ext(fileHeader.Filename))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении файла"})
+		return
+	}
+
+	// Сохранение файла с одновременным вычислением SHA-256
+	hash := sha256.New()
+	size, err := ac.Storage.Save(key, io.TeeReader(io.MultiReader(bytes.NewReader(head), file), hash))
+	if err != nil {
+		log.Printf("Ошибка при сохранении файла вложения: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении файла"})
+		return
+	}
+
+	attachment := models.Attachment{
+		DefectID:   defect.ID,
+		UploaderID: userID.(uint),
+		FileName:   filepath.Base(fileHeader.Filename),
+		MimeType:   mimeType,
+		Size:       size,
+		SHA256:     hex.EncodeToString(hash.Sum(nil)),
+		StorageKey: key,
+	}
+
+	// Создание миниатюры для изображений
+	if strings.HasPrefix(mimeType, "image/") {
+		thumbnailKey, err := ac.createThumbnail(key)
+		if err != nil {
+			log.Printf("Не удалось создать миниатюру для %s: %v", key, err)
+		} else {
+			attachment.ThumbnailKey = thumbnailKey
+			attachment.HasThumbnail = true
+		}
+	}
+
+	// Сохранение вложения в базе данных
+	if result := ac.DB.Create(&attachment); result.Error != nil {
+		ac.Storage.Delete(attachment.StorageKey)
+		if attachment.ThumbnailKey != "" {
+			ac.Storage.Delete(attachment.ThumbnailKey)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении вложения"})
+		return
+	}
+
+	ac.DB.Preload("Uploader").First(&attachment, attachment.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "вложение успешно загружено",
+		"attachment": attachment,
+	})
+}
+
+// получение списка вложений дефекта
+func (ac *AttachmentController) GetDefectAttachments(c *gin.Context) {
+	var defect models.Defect
+	if result := ac.DB.First(&defect, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, ac.DB, defect.ProjectID) {
+		return
+	}
+
+	var attachments []models.Attachment
+	if result := ac.DB.Where("defect_id = ?", defect.ID).Preload("Uploader").Order("created_at").Find(&attachments); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении вложений"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachments": attachments,
+	})
+}
+
+// скачивание файла вложения
+func (ac *AttachmentController) DownloadAttachment(c *gin.Context) {
+	attachment, ok := ac.findAttachment(c)
+	if !ok {
+		return
+	}
+
+	ac.serveFile(c, attachment.StorageKey, attachment.MimeType, attachment.FileName)
+}
+
+// получение миниатюры изображения
+func (ac *AttachmentController) GetAttachmentThumbnail(c *gin.Context) {
+	attachment, ok := ac.findAttachment(c)
+	if !ok {
+		return
+	}
+
+	if attachment.ThumbnailKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "миниатюра для вложения отсутствует"})
+		return
+	}
+
+	ac.serveFile(c, attachment.ThumbnailKey, "image/jpeg", "")
+}
+
+// удаление вложения
+func (ac *AttachmentController) DeleteAttachment(c *gin.Context) {
+	attachment, ok := ac.findAttachment(c, defectEditorRoles...)
+	if !ok {
+		return
+	}
+
+	// удаление вложения из базы данных (мягкое удаление с помощью DeletedAt),
+	// файл остаётся в хранилище до окончательной очистки
+	if result := ac.DB.Delete(&attachment); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении вложения"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "вложение успешно удалено",
+	})
+}
+
+// поиск вложения по ID дефекта и ID вложения из маршрута с проверкой доступа к проекту;
+// если переданы роли, пользователь должен иметь одну из них в проекте дефекта
+func (ac *AttachmentController) findAttachment(c *gin.Context, roles ...models.Role) (models.Attachment, bool) {
+	var attachment models.Attachment
+
+	var defect models.Defect
+	if result := ac.DB.First(&defect, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return attachment, false
+	}
+	if len(roles) > 0 {
+		if _, ok := requireProjectRole(c, ac.DB, defect.ProjectID, roles...); !ok {
+			return attachment, false
+		}
+	} else if !requireProjectAccess(c, ac.DB, defect.ProjectID) {
+		return attachment, false
+	}
+
+	if result := ac.DB.Where("defect_id = ?", defect.ID).First(&attachment, c.Param("attachment_id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "вложение не найдено"})
+		return attachment, false
+	}
+	return attachment, true
+}
+
+// отдача файла из хранилища клиенту
+func (ac *AttachmentController) serveFile(c *gin.Context, key, mimeType, fileName string) {
+	serveStoredFile(c, ac.Storage, key, mimeType, fileName)
+}
+
+// отдача файла по ключу из указанного хранилища
+func serveStoredFile(c *gin.Context, files storage.Storage, key, mimeType, fileName string) {
+	file, err := files.Open(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "файл не найден в хранилище"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при чтении файла"})
+		return
+	}
+	defer file.Close()
+
+	// браузер не должен определять тип загруженного файла по содержимому
+	c.Header("X-Content-Type-Options", "nosniff")
+	if fileName != "" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(fileName)))
+	}
+	c.DataFromReader(http.StatusOK, -1, mimeType, file, nil)
+}
+
+// создание миниатюры изображения и сохранение её в хранилище
+func (ac *AttachmentController) createThumbnail(key string) (string, error) {
+	if err := ac.checkImageSize(key); err != nil {
+		return "", err
+	}
+
+	file, err := ac.Storage.Open(key)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			height = height * thumbnailSize / width
+			width = thumbnailSize
+		} else {
+			width = width * thumbnailSize / height
+			height = thumbnailSize
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return "", err
+	}
+
+	thumbnailKey := strings.TrimSuffix(key, filepath.Ext(key)) + "_thumb.jpg"
+	if _, err := ac.Storage.Save(thumbnailKey, &buf); err != nil {
+		return "", err
+	}
+
+	return thumbnailKey, nil
+}
+
+// проверка размеров изображения по заголовку файла до его декодирования
+func (ac *AttachmentController) checkImageSize(key string) error {
+	file, err := ac.Storage.Open(key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxThumbnailSourcePixels {
+		return fmt.Errorf("размер изображения %dx%d превышает допустимый для миниатюры", cfg.Width, cfg.Height)
+	}
+	return nil
+}
+
+// генерация уникального ключа файла в хранилище
+func newStorageKey(prefix, ext string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	if len(ext) > 10 {
+		ext = ""
+	}
+	return prefix + "/" + hex.EncodeToString(buf) + strings.ToLower(ext), nil
+}
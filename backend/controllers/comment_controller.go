@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 	"systemControl_proj/database"
 	"systemControl_proj/models"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -21,6 +23,31 @@ func NewCommentController() *CommentController {
 	}
 }
 
+// удаленные комментарии, у которых остались неудаленные ответы (на любом уровне вложенности)
+const commentTombstonesSQL = `WITH RECURSIVE ancestors AS (
+		SELECT parent_id AS id FROM comments WHERE defect_id = ? AND deleted_at IS NULL AND parent_id IS NOT NULL
+		UNION
+		SELECT c.parent_id FROM comments c JOIN ancestors ON c.id = ancestors.id WHERE c.parent_id IS NOT NULL
+	) SELECT id FROM ancestors`
+
+// все ответы на указанные комментарии (на любом уровне вложенности)
+const commentRepliesSQL = `WITH RECURSIVE thread AS (
+		SELECT id FROM comments WHERE parent_id IN ?
+		UNION
+		SELECT c.id FROM comments c JOIN thread ON c.parent_id = thread.id
+	) SELECT id FROM thread`
+
+// выборка числа ответов на комментарий, выводимых в списке: неудаленных и заглушек
+// удаленных ответов (параметр — ID дефекта)
+const commentReplyCountSelect = "comments.*, (SELECT COUNT(*) FROM comments replies WHERE replies.parent_id = comments.id" +
+	" AND (replies.deleted_at IS NULL OR replies.id IN (" + commentTombstonesSQL + "))) AS reply_count"
+
+// поля, по которым допускается сортировка комментариев
+var commentSortFields = map[string]string{
+	"id":         "comments.id",
+	"created_at": "comments.created_at",
+}
+
 // создание нового комментария к дефекту
 func (cc *CommentController) CreateComment(c *gin.Context) {
 	var commentCreate models.CommentCreate
@@ -43,21 +70,54 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "указанный дефект не найден"})
 		return
 	}
+	if !requireProjectAccess(c, cc.DB, defect.ProjectID) {
+		return
+	}
+
+	// Ответ допускается только на неудаленный комментарий того же дефекта
+	if commentCreate.ParentID != nil {
+		var parent models.Comment
+		if result := cc.DB.Where("defect_id = ?", defect.ID).First(&parent, *commentCreate.ParentID); result.Error != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "комментарий, на который дается ответ, не найден у этого дефекта"})
+			return
+		}
+	}
 
 	// Создание нового комментария
 	comment := models.Comment{
 		DefectID: commentCreate.DefectID,
+		ParentID: commentCreate.ParentID,
 		UserID:   userID.(uint),
 		Content:  commentCreate.Content,
 	}
 
-	// Сохранение комментария в базе данных
-	if result := cc.DB.Create(&comment); result.Error != nil {
+	// Сохранение комментария в базе данных вместе с упоминаниями и уведомлениями
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		mentioned, err := syncCommentMentions(tx, &comment, defect.ProjectID)
+		if err != nil {
+			return err
+		}
+		if err := publishCommentEvent(tx, models.EventCommentCreated, comment.ID); err != nil {
+			return err
+		}
+		var author models.User
+		if err := tx.First(&author, comment.UserID).Error; err != nil {
+			return err
+		}
+		if err := notifyMentions(tx, &defect, mentioned, &author); err != nil {
+			return err
+		}
+		return notifyNewComment(tx, &defect, mentioned, &author)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении комментария"})
 		return
 	}
 
-	cc.DB.Preload("User").First(&comment, comment.ID)
+	cc.DB.Preload("User").Preload("Mentions.User").First(&comment, comment.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "комментарий успешно создан",
@@ -65,18 +125,232 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 	})
 }
 
-// получение всех комментариев для конкретного дефекта
+// получение комментариев дефекта: плоским списком (view=flat, по умолчанию)
+// или деревом ответов (view=tree, постранично по комментариям верхнего уровня)
 func (cc *CommentController) GetDefectComments(c *gin.Context) {
 	defectID := c.Param("id")
 
+	var defect models.Defect
+	if result := cc.DB.First(&defect, defectID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, cc.DB, defect.ProjectID) {
+		return
+	}
+
+	view := c.DefaultQuery("view", "flat")
+	if view != "flat" && view != "tree" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "параметр view принимает значения flat или tree"})
+		return
+	}
+
+	// неудаленные комментарии и заглушки удаленных, на которые есть ответы
+	visible := cc.DB.Unscoped().Model(&models.Comment{}).
+		Where("comments.defect_id = ?", defect.ID).
+		Where("comments.deleted_at IS NULL OR comments.id IN ("+commentTombstonesSQL+")", defect.ID).
+		Session(&gorm.Session{})
+
+	query := visible
+	if view == "tree" {
+		query = query.Where("comments.parent_id IS NULL")
+	} else {
+		// parent_id отбирает ответы на комментарий, none — комментарии верхнего уровня
+		switch parentID := c.Query("parent_id"); parentID {
+		case "":
+		case "none":
+			query = query.Where("comments.parent_id IS NULL")
+		default:
+			if _, err := strconv.ParseUint(parentID, 10, 64); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный parent_id"})
+				return
+			}
+			query = query.Where("comments.parent_id = ?", parentID)
+		}
+	}
+	query = query.Session(&gorm.Session{})
+	page, perPage := getPagination(c)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении комментариев"})
+		return
+	}
+
+	query, err := applySort(c, query, commentSortFields, "created_at", "comments.id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var comments []models.Comment
-	if result := cc.DB.Where("defect_id = ?", defectID).Preload("User").Find(&comments); result.Error != nil {
+	if result := paginate(query, page, perPage).Select(commentReplyCountSelect, defect.ID).
+		Preload("User").Preload("Mentions.User").Find(&comments); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении комментариев"})
 		return
 	}
+	hideDeletedComments(comments)
+
+	if view == "tree" && len(comments) > 0 {
+		rootIDs := make([]uint, len(comments))
+		for i, comment := range comments {
+			rootIDs[i] = comment.ID
+		}
+		var replies []models.Comment
+		if result := visible.Where("comments.id IN ("+commentRepliesSQL+")", rootIDs).
+			Select(commentReplyCountSelect, defect.ID).Order("comments.created_at, comments.id").
+			Preload("User").Preload("Mentions.User").Find(&replies); result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении комментариев"})
+			return
+		}
+		hideDeletedComments(replies)
+		buildCommentTree(comments, replies)
+	}
+
+	response := pageMeta(total, page, perPage)
+	response["comments"] = comments
+	c.JSON(http.StatusOK, response)
+}
+
+// замена удаленных комментариев заглушками без автора и текста
+func hideDeletedComments(comments []models.Comment) {
+	for i := range comments {
+		if !comments[i].DeletedAt.Valid {
+			continue
+		}
+		comments[i].Deleted = true
+		comments[i].UserID = 0
+		comments[i].User = nil
+		comments[i].Content = ""
+		comments[i].Mentions = []models.CommentMention{}
+		comments[i].EditedAt = nil
+	}
+}
+
+// размещение ответов под родительскими комментариями (ответы упорядочены по времени)
+func buildCommentTree(roots []models.Comment, replies []models.Comment) {
+	children := map[uint][]models.Comment{}
+	for _, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+	}
+
+	var attach func(comments []models.Comment)
+	attach = func(comments []models.Comment) {
+		for i := range comments {
+			comments[i].Replies = children[comments[i].ID]
+			attach(comments[i].Replies)
+		}
+	}
+	attach(roots)
+}
+
+// редактирование комментария его автором; прежний текст сохраняется в истории редакций
+func (cc *CommentController) UpdateComment(c *gin.Context) {
+	var commentUpdate models.CommentUpdate
+	if err := c.ShouldBindJSON(&commentUpdate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := contextUser(c)
+
+	// Проверка существования комментария
+	var comment models.Comment
+	if result := cc.DB.First(&comment, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "комментарий не найден"})
+		return
+	}
+	var defect models.Defect
+	if result := cc.DB.First(&defect, comment.DefectID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, cc.DB, defect.ProjectID) {
+		return
+	}
+
+	// Редактировать комментарий может только его автор
+	if comment.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "редактировать комментарий может только его автор"})
+		return
+	}
+
+	if commentUpdate.Content != comment.Content {
+		err := cc.DB.Transaction(func(tx *gorm.DB) error {
+			revision := models.CommentRevision{
+				CommentID:  comment.ID,
+				Content:    comment.Content,
+				EditedByID: userID,
+			}
+			if err := tx.Create(&revision).Error; err != nil {
+				return err
+			}
+
+			now := time.Now()
+			comment.Content = commentUpdate.Content
+			comment.EditedAt = &now
+			if err := tx.Model(&comment).Updates(map[string]interface{}{
+				"content":   comment.Content,
+				"edited_at": comment.EditedAt,
+			}).Error; err != nil {
+				return err
+			}
+
+			// уведомления получают только пользователи, упомянутые при этом изменении
+			mentioned, err := syncCommentMentions(tx, &comment, defect.ProjectID)
+			if err != nil {
+				return err
+			}
+			if err := publishCommentEvent(tx, models.EventCommentUpdated, comment.ID); err != nil {
+				return err
+			}
+			var author models.User
+			if err := tx.First(&author, comment.UserID).Error; err != nil {
+				return err
+			}
+			return notifyMentions(tx, &defect, mentioned, &author)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении комментария"})
+			return
+		}
+	}
+
+	cc.DB.Select(commentReplyCountSelect, comment.DefectID).Preload("User").Preload("Mentions.User").First(&comment, comment.ID)
 
 	c.JSON(http.StatusOK, gin.H{
-		"comments": comments,
+		"message": "комментарий успешно обновлен",
+		"comment": comment,
+	})
+}
+
+// прежние редакции комментария, начиная с последней
+func (cc *CommentController) GetCommentRevisions(c *gin.Context) {
+	var comment models.Comment
+	if result := cc.DB.First(&comment, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "комментарий не найден"})
+		return
+	}
+	var defect models.Defect
+	if result := cc.DB.First(&defect, comment.DefectID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, cc.DB, defect.ProjectID) {
+		return
+	}
+
+	var revisions []models.CommentRevision
+	if result := cc.DB.Preload("EditedBy").Where("comment_id = ?", comment.ID).
+		Order("created_at DESC, id DESC").Find(&revisions); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении редакций комментария"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comment_id": comment.ID,
+		"edited_at":  comment.EditedAt,
+		"revisions":  revisions,
 	})
 }
 
@@ -104,6 +378,14 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "комментарий не найден"})
 		return
 	}
+	var defect models.Defect
+	if result := cc.DB.First(&defect, comment.DefectID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, cc.DB, defect.ProjectID) {
+		return
+	}
 
 	// Проверка прав на удаление комментария (только автор или менеджер)
 	if comment.UserID != userID.(uint) && userRole.(models.Role) != models.RoleManager {
@@ -111,8 +393,15 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	// Удаление комментария из базы данных
-	if result := cc.DB.Delete(&comment); result.Error != nil {
+	// Удаление комментария из базы данных; ответы на него сохраняются,
+	// а сам комментарий выводится в списке заглушкой
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+		return publishCommentEvent(tx, models.EventCommentDeleted, comment.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении комментария"})
 		return
 	}
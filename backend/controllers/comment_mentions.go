@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"systemControl_proj/models"
+	"systemControl_proj/notify"
+
+	"gorm.io/gorm"
+)
+
+// упоминание пользователя в тексте комментария: @username в начале текста
+// или после символа, который не может входить в имя (например, в email не ищется)
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@-])@([\p{L}\p{N}_.-]+)`)
+
+// имена упомянутых пользователей в нижнем регистре, без повторов
+func parseMentions(content string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		// точка или дефис в конце относятся к тексту, а не к имени: «спасибо, @ivan.»
+		name := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// пользователи, упомянутые в тексте и имеющие доступ к проекту дефекта;
+// неизвестные имена и пользователи вне проекта пропускаются
+func resolveMentions(tx *gorm.DB, content string, projectID uint) ([]models.User, error) {
+	names := parseMentions(content)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var users []models.User
+	if err := tx.Where("LOWER(username) IN ?", names).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	mentioned := make([]models.User, 0, len(users))
+	for _, user := range users {
+		if user.Role == models.RoleManager || isProjectMember(tx, projectID, user.ID) {
+			mentioned = append(mentioned, user)
+		}
+	}
+	return mentioned, nil
+}
+
+// приведение упоминаний комментария в соответствие с его текстом;
+// возвращает пользователей, упомянутых впервые
+func syncCommentMentions(tx *gorm.DB, comment *models.Comment, projectID uint) ([]models.User, error) {
+	users, err := resolveMentions(tx, comment.Content, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []uint
+	if err := tx.Model(&models.CommentMention{}).Where("comment_id = ?", comment.ID).Pluck("user_id", &existing).Error; err != nil {
+		return nil, err
+	}
+	alreadyMentioned := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		alreadyMentioned[id] = true
+	}
+
+	keep := make([]uint, 0, len(users))
+	added := []models.User{}
+	for _, user := range users {
+		keep = append(keep, user.ID)
+		if !alreadyMentioned[user.ID] {
+			added = append(added, user)
+		}
+	}
+
+	// удаление упоминаний, которых больше нет в тексте
+	remove := tx.Where("comment_id = ?", comment.ID)
+	if len(keep) > 0 {
+		remove = remove.Where("user_id NOT IN ?", keep)
+	}
+	if err := remove.Delete(&models.CommentMention{}).Error; err != nil {
+		return nil, err
+	}
+
+	for _, user := range added {
+		mention := models.CommentMention{CommentID: comment.ID, UserID: user.ID}
+		if err := tx.Create(&mention).Error; err != nil {
+			return nil, err
+		}
+	}
+	return added, nil
+}
+
+// уведомления упомянутым пользователям (кроме автора комментария)
+func notifyMentions(tx *gorm.DB, defect *models.Defect, mentioned []models.User, author *models.User) error {
+	recipients := []uint{}
+	for _, user := range mentioned {
+		if user.ID != author.ID {
+			recipients = append(recipients, user.ID)
+		}
+	}
+	message := fmt.Sprintf("%s упомянул(а) вас в комментарии к дефекту «%s»", userDisplayName(author), defect.Title)
+	return notify.DefectUsers(tx, defect, models.NotificationCommentMention, author.ID, recipients, message)
+}
+
+// уведомление о новом комментарии получателям дефекта, кроме упомянутых:
+// они уже получили уведомление об упоминании
+func notifyNewComment(tx *gorm.DB, defect *models.Defect, mentioned []models.User, author *models.User) error {
+	recipients, err := defectRecipients(tx, defect, author.ID)
+	if err != nil {
+		return err
+	}
+	skip := make(map[uint]bool, len(mentioned))
+	for _, user := range mentioned {
+		skip[user.ID] = true
+	}
+	filtered := recipients[:0]
+	for _, id := range recipients {
+		if !skip[id] {
+			filtered = append(filtered, id)
+		}
+	}
+	message := fmt.Sprintf("Новый комментарий к дефекту «%s» от %s", defect.Title, author.Username)
+	return notify.DefectUsers(tx, defect, models.NotificationDefectCommented, author.ID, filtered, message)
+}
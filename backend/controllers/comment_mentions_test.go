@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"без упоминаний", "Трещина устранена", []string{}},
+		{"в начале текста", "@ivanov проверьте, пожалуйста", []string{"ivanov"}},
+		{"несколько пользователей", "@ivanov и @petrova, посмотрите", []string{"ivanov", "petrova"}},
+		{"регистр и повторы", "@Ivanov, @IVANOV, @ivanov", []string{"ivanov"}},
+		{"точка в конце предложения", "Спасибо, @ivan.", []string{"ivan"}},
+		{"точка и дефис внутри имени", "@ivan.petrov-2 проверит", []string{"ivan.petrov-2"}},
+		{"кириллица в имени", "Передано @прораб_1", []string{"прораб_1"}},
+		{"email не упоминание", "Пишите на ivan@example.com", []string{}},
+		{"после скобки и переноса строки", "(@ivanov)\n@petrova", []string{"ivanov", "petrova"}},
+		{"одиночный символ @", "Смотрите @ и @.", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseMentions(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions(%q) = %v, ожидалось %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
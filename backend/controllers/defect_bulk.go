@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"systemControl_proj/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ошибка, означающая, что хотя бы один дефект не прошёл проверку
+var errBulkRejected = errors.New("массовая операция отклонена")
+
+// массовое изменение или удаление дефектов.
+// Операция атомарна: каждый дефект проверяется теми же правилами, что и при
+// одиночном обновлении или удалении, и если хотя бы один не проходит проверку,
+// не применяется ничего. В ответе возвращается результат по каждому ID.
+func (dc *DefectController) BulkDefects(c *gin.Context) {
+	var request models.DefectBulkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, role := contextUser(c)
+
+	var update models.DefectUpdate
+	switch request.Action {
+	case models.BulkDefectUpdate:
+		update = models.DefectUpdate{
+			Status:     request.Status,
+			Priority:   request.Priority,
+			AssigneeID: request.AssigneeID,
+			DueDate:    request.DueDate,
+		}
+		if update.Status == "" && update.Priority == "" && update.AssigneeID == 0 && update.DueDate.IsZero() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "не указано ни одного изменения"})
+			return
+		}
+		if update.Priority != "" && !update.Priority.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "недопустимый приоритет дефекта"})
+			return
+		}
+	case models.BulkDefectDelete:
+		// удалять дефекты могут только менеджер и инженер, как и по одному
+		if role != models.RoleManager && role != models.RoleEngineer {
+			c.JSON(http.StatusForbidden, gin.H{"error": "недостаточно прав для удаления дефектов"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "параметр action принимает значения update или delete"})
+		return
+	}
+
+	// повторяющиеся ID обрабатываются один раз, порядок сохраняется
+	ids := make([]uint, 0, len(request.IDs))
+	seen := make(map[uint]bool, len(request.IDs))
+	for _, id := range request.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	results := make([]gin.H, 0, len(ids))
+	err := dc.DB.Transaction(func(tx *gorm.DB) error {
+		// блокировка строк, чтобы проверенные дефекты не изменились до сохранения
+		var defects []models.Defect
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).Order("id").Find(&defects).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]*models.Defect, len(defects))
+		for i := range defects {
+			byID[defects[i].ID] = &defects[i]
+		}
+
+		// проверка всех дефектов до применения изменений
+		type bulkItem struct {
+			before models.Defect
+			after  *models.Defect
+		}
+		items := make([]bulkItem, 0, len(ids))
+		failed := false
+		for _, id := range ids {
+			defect, ok := byID[id]
+			if !ok {
+				failed = true
+				results = append(results, gin.H{"id": id, "result": "failed", "code": http.StatusNotFound, "error": "дефект не найден"})
+				continue
+			}
+			itemRole, member := projectRole(c, tx, defect.ProjectID)
+			if !member {
+				failed = true
+				results = append(results, gin.H{"id": id, "result": "failed", "code": http.StatusForbidden, "error": "нет доступа к проекту"})
+				continue
+			}
+			if !hasProjectRole(itemRole, defectEditorRoles) {
+				failed = true
+				results = append(results, gin.H{"id": id, "result": "failed", "code": http.StatusForbidden, "error": "недостаточно прав в проекте"})
+				continue
+			}
+			before := *defect
+			if request.Action == models.BulkDefectUpdate {
+				if updateErr := dc.applyDefectUpdate(c, defect, update); updateErr != nil {
+					failed = true
+					result := gin.H{"id": id, "result": "failed", "code": updateErr.Status}
+					for key, value := range updateErr.Body {
+						result[key] = value
+					}
+					results = append(results, result)
+					continue
+				}
+			}
+			items = append(items, bulkItem{before: before, after: defect})
+			results = append(results, gin.H{"id": id, "result": "ok", "code": http.StatusOK})
+		}
+		if failed {
+			return errBulkRejected
+		}
+
+		for i, item := range items {
+			if request.Action == models.BulkDefectDelete {
+				if err := deleteDefect(tx, item.after, userID); err != nil {
+					return err
+				}
+				results[i]["result"] = "deleted"
+				continue
+			}
+			if err := saveDefectChanges(tx, &item.before, item.after, userID); err != nil {
+				return err
+			}
+			results[i]["result"] = "updated"
+		}
+		return nil
+	})
+	if errors.Is(err, errBulkRejected) {
+		// ни один дефект не изменён; успешно проверенные отмечены как skipped
+		for _, result := range results {
+			if result["result"] == "ok" {
+				result["result"] = "skipped"
+			}
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "операция не применена: часть дефектов не прошла проверку",
+			"results": results,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при выполнении массовой операции"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "массовая операция выполнена",
+		"action":  request.Action,
+		"results": results,
+	})
+}
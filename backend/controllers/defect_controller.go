@@ -1,27 +1,44 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"systemControl_proj/config"
 	"systemControl_proj/database"
 	"systemControl_proj/models"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // контроллер запросов связанных с дефектами
 type DefectController struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Config *config.Config
 }
 
 // создание нового экземпляра контроллера дефектов
-func NewDefectController() *DefectController {
+func NewDefectController(config *config.Config) *DefectController {
 	return &DefectController{
-		DB: database.DB,
+		DB:     database.DB,
+		Config: config,
 	}
 }
 
+// поля, по которым допускается сортировка списка дефектов
+var defectSortFields = map[string]string{
+	"id":         "defects.id",
+	"title":      "defects.title",
+	"status":     "defects.status",
+	"priority":   "CASE defects.priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END",
+	"due_date":   "defects.due_date",
+	"created_at": "defects.created_at",
+	"updated_at": "defects.updated_at",
+}
+
 // создание нового дефекта
 func (dc *DefectController) CreateDefect(c *gin.Context) {
 	var defectCreate models.DefectCreate
@@ -38,30 +55,59 @@ func (dc *DefectController) CreateDefect(c *gin.Context) {
 		return
 	}
 
-	// Получение роли пользователя из контекста
-	userRole, _ := c.Get("role")
-
 	// Проверка существования проекта
 	var project models.Project
 	if result := dc.DB.First(&project, defectCreate.ProjectID); result.Error != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "указанный проект не найден"})
 		return
 	}
+	if _, ok := requireProjectRole(c, dc.DB, project.ID, defectEditorRoles...); !ok {
+		return
+	}
+
+	if defectCreate.Priority != "" && !defectCreate.Priority.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "недопустимый приоритет дефекта"})
+		return
+	}
 
 	// Проверка существования исполнителя, если он указан
+	var assignee *models.User
 	if defectCreate.AssigneeID != 0 {
-		var assignee models.User
-		if result := dc.DB.First(&assignee, defectCreate.AssigneeID); result.Error != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "указанный исполнитель не найден"})
+		var assigneeErr *defectUpdateError
+		if assignee, assigneeErr = dc.checkDefectAssignee(c, project.ID, defectCreate.AssigneeID); assigneeErr != nil {
+			c.JSON(assigneeErr.Status, assigneeErr.Body)
 			return
 		}
-		// Если текущий пользователь инженер, он может назначать только инженера
-		if role, ok := userRole.(models.Role); ok && role == models.RoleEngineer {
-			if assignee.Role != models.RoleEngineer {
-				c.JSON(http.StatusForbidden, gin.H{"error": "инженер может назначать исполнителем только инженера"})
-				return
-			}
+	}
+
+	// Этап, если указан, должен относиться к проекту дефекта
+	if defectCreate.StageID != nil && !stageBelongsToProject(dc.DB, *defectCreate.StageID, project.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "указанный этап не найден в проекте"})
+		return
+	}
+	if defectCreate.LocationID != nil && !locationBelongsToProject(dc.DB, *defectCreate.LocationID, project.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "указанное место не найдено в проекте"})
+		return
+	}
+
+	if err := validateCoordinates(defectCreate.Latitude, defectCreate.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Метка на плане; место дефекта по умолчанию берется из плана
+	if defectCreate.PlanID != nil {
+		plan, err := validateDefectPin(dc.DB, project.ID, defectCreate.LocationID, *defectCreate.PlanID, defectCreate.PinX, defectCreate.PinY)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if defectCreate.LocationID == nil {
+			defectCreate.LocationID = &plan.LocationID
 		}
+	} else if defectCreate.PinX != nil || defectCreate.PinY != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "для метки необходимо указать план plan_id"})
+		return
 	}
 
 	// Создание нового дефекта
@@ -69,10 +115,21 @@ func (dc *DefectController) CreateDefect(c *gin.Context) {
 		Title:       defectCreate.Title,
 		Description: defectCreate.Description,
 		ProjectID:   defectCreate.ProjectID,
+		StageID:     defectCreate.StageID,
+		LocationID:  defectCreate.LocationID,
+		PlanID:      defectCreate.PlanID,
+		PinX:        defectCreate.PinX,
+		PinY:        defectCreate.PinY,
+		Latitude:    defectCreate.Latitude,
+		Longitude:   defectCreate.Longitude,
 		Status:      models.DefectStatusNew,
 		ReporterID:  userID.(uint),
-		AssigneeID:  defectCreate.AssigneeID,
-		DueDate:     defectCreate.DueDate,
+	}
+	if assignee != nil {
+		defect.AssigneeID = &assignee.ID
+	}
+	if !defectCreate.DueDate.IsZero() {
+		defect.DueDate = &defectCreate.DueDate
 	}
 
 	// Установка приоритета по умолчанию, если не указан
@@ -82,8 +139,11 @@ func (dc *DefectController) CreateDefect(c *gin.Context) {
 		defect.Priority = models.DefectPriorityMedium
 	}
 
-	// Сохранение дефекта в базе данных
-	if result := dc.DB.Create(&defect); result.Error != nil {
+	// Сохранение дефекта в базе данных вместе с уведомлением о назначении
+	err := dc.DB.Transaction(func(tx *gorm.DB) error {
+		return createDefect(tx, &defect, assignee)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении дефекта"})
 		return
 	}
@@ -98,47 +158,52 @@ func (dc *DefectController) CreateDefect(c *gin.Context) {
 func (dc *DefectController) GetAllDefects(c *gin.Context) {
 	var defects []models.Defect
 
-	// получение параметров запроса для фильтрации
-	// Сначала проверяем параметр из URL пути (для маршрута /projects/:id/defects)
-	projectID := c.Param("id")
-	// Если параметра в пути нет, проверяем query-параметр
-	if projectID == "" {
-		projectID = c.Query("project_id")
+	query, err := applyDefectFilters(c, dc.DB.Model(&models.Defect{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	query = query.Session(&gorm.Session{})
 
-	status := c.Query("status")
-	priority := c.Query("priority")
-	assigneeID := c.Query("assignee_id")
-	reporterID := c.Query("reporter_id")
-
-	query := dc.DB.Preload("Project").Preload("Reporter").Preload("Assignee")
+	page, perPage := getPagination(c)
 
-	// применение фильтров
-	if projectID != "" {
-		query = query.Where("project_id = ?", projectID)
-	}
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-	if priority != "" {
-		query = query.Where("priority = ?", priority)
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении дефектов"})
+		return
 	}
-	if assigneeID != "" {
-		query = query.Where("assignee_id = ?", assigneeID)
+
+	// при поиске результаты упорядочиваются по релевантности и дополняются фрагментами с подсветкой
+	if search := c.Query("q"); search != "" {
+		query = query.Select(`defects.*,
+			ts_rank(defects.search_vector, websearch_to_tsquery('russian', ?)) AS search_rank,
+			ts_headline('russian',
+				COALESCE(defects.title, '') || ' ' || COALESCE(defects.description, '') || ' ' || COALESCE((
+					SELECT string_agg(comments.content, ' ') FROM comments
+					WHERE comments.defect_id = defects.id AND comments.deleted_at IS NULL
+				), ''),
+				websearch_to_tsquery('russian', ?),
+				'StartSel=<mark>, StopSel=</mark>, MaxFragments=3, FragmentDelimiter=" … "') AS snippet`, search, search)
+		if c.Query("sort") == "" {
+			query = query.Order("search_rank DESC")
+		}
 	}
-	if reporterID != "" {
-		query = query.Where("reporter_id = ?", reporterID)
+
+	query, err = applySort(c, query, defectSortFields, "-created_at", "defects.id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Получение дефектов из базы данных
-	if result := query.Find(&defects); result.Error != nil {
+	// Получение страницы дефектов из базы данных
+	if result := paginate(query, page, perPage).Preload("Project").Preload("Stage").Preload("Location").Preload("Reporter").Preload("Assignee").Find(&defects); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении дефектов"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"defects": defects,
-	})
+	response := pageMeta(total, page, perPage)
+	response["defects"] = defects
+	c.JSON(http.StatusOK, response)
 }
 
 // получение конкретного дефекта по ID
@@ -146,13 +211,90 @@ func (dc *DefectController) GetDefect(c *gin.Context) {
 	id := c.Param("id")
 
 	var defect models.Defect
-	if result := dc.DB.Preload("Project").Preload("Reporter").Preload("Assignee").Preload("Comments").Preload("Comments.User").First(&defect, id); result.Error != nil {
+	if result := defectWithRelations(dc.DB).First(&defect, id); result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
 		return
 	}
+	if !requireProjectAccess(c, dc.DB, defect.ProjectID) {
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	c.Header("ETag", versionETag(defect.Version))
+	response := gin.H{
 		"defect": defect,
+	}
+
+	// История изменений включается по запросу (?include=history)
+	if c.Query("include") == "history" {
+		var history []models.DefectHistory
+		if result := dc.DB.Where("defect_id = ?", defect.ID).Preload("User").Order("created_at DESC, id DESC").Find(&history); result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении истории изменений"})
+			return
+		}
+		response["history"] = history
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// получение истории изменений дефекта
+func (dc *DefectController) GetDefectHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	var defect models.Defect
+	if result := dc.DB.First(&defect, id); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, dc.DB, defect.ProjectID) {
+		return
+	}
+
+	page, perPage := getPagination(c)
+
+	var total int64
+	if result := dc.DB.Model(&models.DefectHistory{}).Where("defect_id = ?", defect.ID).Count(&total); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении истории изменений"})
+		return
+	}
+
+	var history []models.DefectHistory
+	if result := paginate(dc.DB.Where("defect_id = ?", defect.ID), page, perPage).
+		Preload("User").
+		Order("created_at DESC, id DESC").
+		Find(&history); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении истории изменений"})
+		return
+	}
+
+	response := pageMeta(total, page, perPage)
+	response["history"] = history
+	c.JSON(http.StatusOK, response)
+}
+
+// получение журнала эскалаций просроченного дефекта
+func (dc *DefectController) GetDefectEscalations(c *gin.Context) {
+	var defect models.Defect
+	if result := dc.DB.First(&defect, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, dc.DB, defect.ProjectID) {
+		return
+	}
+
+	var escalations []models.DefectEscalation
+	if result := dc.DB.Where("defect_id = ?", defect.ID).
+		Preload("Recipient").
+		Order("created_at DESC, id DESC").
+		Find(&escalations); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении эскалаций"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"overdue_since": defect.OverdueSince,
+		"escalations":   escalations,
 	})
 }
 
@@ -177,49 +319,240 @@ func (dc *DefectController) UpdateDefect(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
 		return
 	}
+	if _, ok := requireProjectRole(c, dc.DB, defect.ProjectID, defectEditorRoles...); !ok {
+		return
+	}
+	// Проверка версии из If-Match: дефект мог измениться после чтения клиентом
+	if !ifMatchVersion(c, defect.Version) {
+		dc.respondDefectConflict(c, defect.ID)
+		return
+	}
+	before := defect
 
 	// Обновление полей дефекта
-	if defectUpdate.Title != "" {
-		defect.Title = defectUpdate.Title
+	if updateErr := dc.applyDefectUpdate(c, &defect, defectUpdate); updateErr != nil {
+		c.JSON(updateErr.Status, updateErr.Body)
+		return
+	}
+
+	userID, _ := contextUser(c)
+
+	// Сохранение дефекта вместе с записями истории изменений
+	err = dc.DB.Transaction(func(tx *gorm.DB) error {
+		return saveDefectChanges(tx, &before, &defect, userID)
+	})
+	if errors.Is(err, errVersionConflict) {
+		dc.respondDefectConflict(c, defect.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении дефекта"})
+		return
 	}
-	if defectUpdate.Description != "" {
-		defect.Description = defectUpdate.Description
+
+	c.Header("ETag", versionETag(defect.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "дефект успешно обновлен",
+		"defect":  defect,
+	})
+}
+
+// сохранение нового дефекта с событием создания и уведомлением о назначении
+func createDefect(tx *gorm.DB, defect *models.Defect, assignee *models.User) error {
+	if err := tx.Create(defect).Error; err != nil {
+		return err
 	}
-	if defectUpdate.Status != "" {
-		defect.Status = defectUpdate.Status
+	if err := publishDefectEvent(tx, models.EventDefectCreated, defect.ID); err != nil {
+		return err
 	}
-	if defectUpdate.Priority != "" {
-		defect.Priority = defectUpdate.Priority
+	if assignee == nil {
+		return nil
 	}
-	if defectUpdate.AssigneeID != 0 {
-		var assignee models.User
-		if result := dc.DB.First(&assignee, defectUpdate.AssigneeID); result.Error != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "указанный исполнитель не найден"})
-			return
+	message := fmt.Sprintf("Дефект «%s» назначен исполнителю %s", defect.Title, userDisplayName(assignee))
+	return notifyDefectEvent(tx, defect, models.NotificationDefectAssigned, defect.ReporterID, message)
+}
+
+// проверка исполнителя дефекта: пользователь существует, состоит в проекте
+// (или является менеджером), а инженер может назначать только инженера
+func (dc *DefectController) checkDefectAssignee(c *gin.Context, projectID, assigneeID uint) (*models.User, *defectUpdateError) {
+	var assignee models.User
+	if result := dc.DB.First(&assignee, assigneeID); result.Error != nil {
+		return nil, &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": "указанный исполнитель не найден"}}
+	}
+	if assignee.Role != models.RoleManager && !isProjectMember(dc.DB, projectID, assignee.ID) {
+		return nil, &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": "указанный исполнитель не является участником проекта"}}
+	}
+	// Если текущий пользователь в проекте инженер, он может назначать только инженера
+	if role, _ := projectRole(c, dc.DB, projectID); role == models.RoleEngineer && assignee.Role != models.RoleEngineer {
+		return nil, &defectUpdateError{Status: http.StatusForbidden, Body: gin.H{"error": "инженер может назначать исполнителем только инженера"}}
+	}
+	return &assignee, nil
+}
+
+// дефект со связанными проектом, этапом, местом, участниками, комментариями и вложениями
+func defectWithRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Project").Preload("Stage").Preload("Location").Preload("Reporter").Preload("Assignee").Preload("Comments").Preload("Comments.User").Preload("Attachments")
+}
+
+// ответ 412 с текущим состоянием дефекта, измененного после чтения клиентом
+func (dc *DefectController) respondDefectConflict(c *gin.Context, defectID uint) {
+	var current models.Defect
+	if result := defectWithRelations(dc.DB).First(&current, defectID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	c.Header("ETag", versionETag(current.Version))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":           "дефект был изменен другим пользователем, обновите данные и повторите изменение",
+		"current_version": current.Version,
+		"defect":          current,
+	})
+}
+
+// ошибка проверки изменений дефекта: HTTP-статус и тело ответа
+type defectUpdateError struct {
+	Status int
+	Body   gin.H
+}
+
+func (e *defectUpdateError) Error() string {
+	return fmt.Sprint(e.Body["error"])
+}
+
+// применение изменений к дефекту с проверкой рабочего процесса,
+// исполнителя и привязок к проекту; дефект изменяется только в памяти
+func (dc *DefectController) applyDefectUpdate(c *gin.Context, defect *models.Defect, update models.DefectUpdate) *defectUpdateError {
+	if update.Title != "" {
+		defect.Title = update.Title
+	}
+	if update.Description != "" {
+		defect.Description = update.Description
+	}
+	if update.Status != "" && update.Status != defect.Status {
+		if !update.Status.IsValid() {
+			return &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": "недопустимый статус дефекта"}}
+		}
+		// Проверка перехода по рабочему процессу с учётом роли в проекте
+		role, _ := projectRole(c, dc.DB, defect.ProjectID)
+		if !models.CanTransitionDefect(defect.Status, update.Status, role) {
+			return &defectUpdateError{Status: http.StatusConflict, Body: gin.H{
+				"error":               "недопустимый переход статуса дефекта",
+				"current_status":      defect.Status,
+				"requested_status":    update.Status,
+				"allowed_transitions": models.AllowedDefectTransitions(defect.Status, role),
+			}}
 		}
-		// Если текущий пользователь инженер, он может назначать только инженера
-		userRole, _ := c.Get("role")
-		if role, ok := userRole.(models.Role); ok && role == models.RoleEngineer {
-			if assignee.Role != models.RoleEngineer {
-				c.JSON(http.StatusForbidden, gin.H{"error": "инженер может назначать исполнителем только инженера"})
-				return
+		defect.Status = update.Status
+	}
+	if update.Priority != "" {
+		if !update.Priority.IsValid() {
+			return &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": "недопустимый приоритет дефекта"}}
+		}
+		defect.Priority = update.Priority
+	}
+	if update.AssigneeID != 0 {
+		if _, assigneeErr := dc.checkDefectAssignee(c, defect.ProjectID, update.AssigneeID); assigneeErr != nil {
+			return assigneeErr
+		}
+		assigneeID := update.AssigneeID
+		defect.AssigneeID = &assigneeID
+	}
+	if !update.DueDate.IsZero() {
+		dueDate := update.DueDate
+		defect.DueDate = &dueDate
+	}
+	if update.StageID != nil {
+		if *update.StageID == 0 {
+			defect.StageID = nil
+		} else {
+			if !stageBelongsToProject(dc.DB, *update.StageID, defect.ProjectID) {
+				return &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": "указанный этап не найден в проекте"}}
 			}
+			defect.StageID = update.StageID
 		}
-		defect.AssigneeID = defectUpdate.AssigneeID
 	}
-	if !defectUpdate.DueDate.IsZero() {
-		defect.DueDate = defectUpdate.DueDate
+	if update.LocationID != nil {
+		if *update.LocationID == 0 {
+			defect.LocationID = nil
+		} else {
+			if !locationBelongsToProject(dc.DB, *update.LocationID, defect.ProjectID) {
+				return &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": "указанное место не найдено в проекте"}}
+			}
+			defect.LocationID = update.LocationID
+		}
+	}
+	if update.PlanID != nil && *update.PlanID == 0 {
+		defect.PlanID, defect.PinX, defect.PinY = nil, nil, nil
+	} else if update.PlanID != nil || update.PinX != nil || update.PinY != nil {
+		// перенос метки: недостающие значения берутся из текущей метки
+		planID, x, y := defect.PlanID, defect.PinX, defect.PinY
+		if update.PlanID != nil {
+			planID = update.PlanID
+		}
+		if update.PinX != nil {
+			x = update.PinX
+		}
+		if update.PinY != nil {
+			y = update.PinY
+		}
+		if planID == nil {
+			return &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": "для метки необходимо указать план plan_id"}}
+		}
+		if _, err := validateDefectPin(dc.DB, defect.ProjectID, defect.LocationID, *planID, x, y); err != nil {
+			return &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": err.Error()}}
+		}
+		defect.PlanID, defect.PinX, defect.PinY = planID, x, y
+	} else if update.LocationID != nil && defect.PlanID != nil {
+		// при смене места метка должна остаться на плане этого места
+		if _, err := validateDefectPin(dc.DB, defect.ProjectID, defect.LocationID, *defect.PlanID, defect.PinX, defect.PinY); err != nil {
+			return &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": err.Error()}}
+		}
+	}
+	if update.Latitude != nil || update.Longitude != nil {
+		if err := validateCoordinates(update.Latitude, update.Longitude); err != nil {
+			return &defectUpdateError{Status: http.StatusBadRequest, Body: gin.H{"error": err.Error()}}
+		}
+		defect.Latitude, defect.Longitude = update.Latitude, update.Longitude
 	}
 
-	if result := dc.DB.Save(&defect); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении дефекта"})
-		return
+	return nil
+}
+
+// сохранение изменений дефекта вместе с историей, уведомлениями и событиями;
+// отметку просрочки ведет планировщик, поэтому она не перезаписывается
+func saveDefectChanges(tx *gorm.DB, before, after *models.Defect, userID uint) error {
+	if err := saveVersioned(tx, after, &after.Version, "overdue_since"); err != nil {
+		return err
 	}
+	if err := recordDefectHistory(tx, before, after, userID); err != nil {
+		return err
+	}
+	if err := notifyDefectChanges(tx, before, after, userID); err != nil {
+		return err
+	}
+	return publishDefectChanges(tx, before, after)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "дефект успешно обновлен",
-		"defect":  defect,
-	})
+// снятие привязки дефектов к удаляемому этапу, месту или плану. Действующие
+// дефекты изменяются по одному так же, как при обновлении (версия, история,
+// уведомления и события); у удаленных дефектов ссылка только очищается,
+// чтобы не нарушать внешний ключ
+func unlinkDefects(tx *gorm.DB, column string, id uint, columns map[string]interface{}, clear func(*models.Defect), userID uint) error {
+	var defects []models.Defect
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(column+" = ?", id).Order("id").Find(&defects).Error; err != nil {
+		return err
+	}
+	for i := range defects {
+		before := defects[i]
+		clear(&defects[i])
+		if err := saveDefectChanges(tx, &before, &defects[i], userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Model(&models.Defect{}).
+		Where(column+" = ? AND deleted_at IS NOT NULL", id).
+		UpdateColumns(columns).Error
 }
 
 // удаление дефекта
@@ -232,9 +565,17 @@ func (dc *DefectController) DeleteDefect(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
 		return
 	}
+	if _, ok := requireProjectRole(c, dc.DB, defect.ProjectID, defectEditorRoles...); !ok {
+		return
+	}
+
+	userID, _ := contextUser(c)
 
 	// удаление дефекта из базы данных (мягкое удаление с помощью DeletedAt)
-	if result := dc.DB.Delete(&defect); result.Error != nil {
+	err := dc.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteDefect(tx, &defect, userID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении дефекта"})
 		return
 	}
@@ -243,3 +584,15 @@ func (dc *DefectController) DeleteDefect(c *gin.Context) {
 		"message": "дефект успешно удален",
 	})
 }
+
+// мягкое удаление дефекта с уведомлениями и событием удаления
+func deleteDefect(tx *gorm.DB, defect *models.Defect, userID uint) error {
+	if err := tx.Delete(defect).Error; err != nil {
+		return err
+	}
+	if err := publishDefectEvent(tx, models.EventDefectDeleted, defect.ID); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Дефект «%s» удалён", defect.Title)
+	return notifyDefectEvent(tx, defect, models.NotificationDefectDeleted, userID, message)
+}
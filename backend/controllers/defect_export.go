@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"systemControl_proj/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// строка реестра дефектов для выгрузки
+type defectExportRow struct {
+	ID           uint
+	Title        string
+	Description  string
+	ProjectName  string
+	Status       models.DefectStatus
+	Priority     models.DefectPriority
+	ReporterName string
+	AssigneeName string
+	DueDate      *time.Time
+	Latitude     *float64
+	Longitude    *float64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	LastComment  string
+}
+
+// заголовки столбцов реестра дефектов
+var defectExportHeaders = []string{
+	"№", "Название", "Описание", "Проект", "Статус", "Приоритет",
+	"Автор", "Исполнитель", "Срок устранения", "Создан", "Обновлён", "Последний комментарий",
+}
+
+// названия статусов дефектов для выгрузки
+var defectStatusLabels = map[models.DefectStatus]string{
+	models.DefectStatusNew:        "Новый",
+	models.DefectStatusInProgress: "В работе",
+	models.DefectStatusReview:     "На проверке",
+	models.DefectStatusClosed:     "Закрыт",
+	models.DefectStatusCanceled:   "Отменён",
+}
+
+// названия приоритетов дефектов для выгрузки
+var defectPriorityLabels = map[models.DefectPriority]string{
+	models.DefectPriorityLow:    "Низкий",
+	models.DefectPriorityMedium: "Средний",
+	models.DefectPriorityHigh:   "Высокий",
+}
+
+// защита от подстановки формул (CSV injection): текст пользователя, который
+// начинается с =, +, -, @, табуляции или перевода строки, Excel при открытии CSV
+// выполнил бы как формулу, поэтому такие значения выводятся с апострофом в начале.
+// В XLSX текст записывается строковыми ячейками, которые не вычисляются,
+// поэтому там апостроф не нужен
+func spreadsheetText(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
+
+// обратное преобразование для импорта: апостроф, добавленный при выгрузке, снимается
+func unescapeSpreadsheetText(value string) string {
+	if len(value) > 1 && value[0] == '\'' && spreadsheetText(value[1:]) != value[1:] {
+		return value[1:]
+	}
+	return value
+}
+
+// название статуса дефекта (неизвестные статусы выводятся как есть)
+func defectStatusLabel(s models.DefectStatus) string {
+	if label, ok := defectStatusLabels[s]; ok {
+		return label
+	}
+	return string(s)
+}
+
+// название приоритета дефекта (неизвестные приоритеты выводятся как есть)
+func defectPriorityLabel(p models.DefectPriority) string {
+	if label, ok := defectPriorityLabels[p]; ok {
+		return label
+	}
+	return string(p)
+}
+
+// выгрузка реестра дефектов в CSV, XLSX или GeoJSON
+func (dc *DefectController) ExportDefects(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" && format != "geojson" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неподдерживаемый формат выгрузки (допустимо csv, xlsx или geojson)"})
+		return
+	}
+
+	query, err := applyDefectFilters(c, dc.DB.Model(&models.Defect{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// в GeoJSON попадают только дефекты с координатами
+	if format == "geojson" {
+		query = query.Where("defects.latitude IS NOT NULL AND defects.longitude IS NOT NULL")
+	}
+
+	rows, err := query.Select(`defects.id, defects.title, defects.description,
+			COALESCE(projects.name, '') AS project_name,
+			defects.status, defects.priority,
+			COALESCE(NULLIF(reporter.full_name, ''), reporter.username, '') AS reporter_name,
+			COALESCE(NULLIF(assignee.full_name, ''), assignee.username, '') AS assignee_name,
+			defects.due_date, defects.latitude, defects.longitude, defects.created_at, defects.updated_at,
+			COALESCE(last_comment.content, '') AS last_comment`).
+		Joins("LEFT JOIN projects ON projects.id = defects.project_id").
+		Joins("LEFT JOIN users AS reporter ON reporter.id = defects.reporter_id").
+		Joins("LEFT JOIN users AS assignee ON assignee.id = defects.assignee_id").
+		Joins(`LEFT JOIN LATERAL (
+			SELECT comments.content FROM comments
+			WHERE comments.defect_id = defects.id AND comments.deleted_at IS NULL
+			ORDER BY comments.created_at DESC, comments.id DESC LIMIT 1
+		) AS last_comment ON TRUE`).
+		Order("defects.id").
+		Rows()
+	if err != nil {
+		log.Printf("Ошибка при выгрузке дефектов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении дефектов"})
+		return
+	}
+	defer rows.Close()
+
+	fileName := fmt.Sprintf("defects_%s.%s", time.Now().Format("2006-01-02"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	// построчная запись реестра
+	next := func() (*defectExportRow, error) {
+		if !rows.Next() {
+			return nil, rows.Err()
+		}
+		var row defectExportRow
+		if err := dc.DB.ScanRows(rows, &row); err != nil {
+			return nil, err
+		}
+		return &row, nil
+	}
+
+	switch format {
+	case "csv":
+		err = writeDefectsCSV(c, next)
+	case "xlsx":
+		err = writeDefectsXLSX(c, next)
+	case "geojson":
+		err = writeDefectsGeoJSON(c, next)
+	}
+	if err != nil {
+		// заголовки уже отправлены, поэтому ошибка только записывается в журнал
+		log.Printf("Ошибка при формировании выгрузки дефектов: %v", err)
+	}
+}
+
+// объект GeoJSON Feature для дефекта (координаты в порядке долгота, широта)
+type defectFeature struct {
+	Type     string                 `json:"type"`
+	ID       uint                   `json:"id"`
+	Geometry defectPoint            `json:"geometry"`
+	Props    map[string]interface{} `json:"properties"`
+}
+
+type defectPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// запись дефектов в GeoJSON FeatureCollection для ГИС
+func writeDefectsGeoJSON(c *gin.Context, next func() (*defectExportRow, error)) error {
+	c.Header("Content-Type", "application/geo+json")
+	c.Status(http.StatusOK)
+
+	if _, err := c.Writer.WriteString(`{"type":"FeatureCollection","features":[`); err != nil {
+		return err
+	}
+
+	for first := true; ; first = false {
+		row, err := next()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+
+		var dueDate interface{}
+		if row.DueDate != nil {
+			dueDate = row.DueDate.Format("2006-01-02")
+		}
+		feature, err := json.Marshal(defectFeature{
+			Type:     "Feature",
+			ID:       row.ID,
+			Geometry: defectPoint{Type: "Point", Coordinates: [2]float64{*row.Longitude, *row.Latitude}},
+			Props: map[string]interface{}{
+				"id":             row.ID,
+				"title":          row.Title,
+				"project":        row.ProjectName,
+				"status":         row.Status,
+				"status_label":   defectStatusLabel(row.Status),
+				"priority":       row.Priority,
+				"priority_label": defectPriorityLabel(row.Priority),
+				"reporter":       row.ReporterName,
+				"assignee":       row.AssigneeName,
+				"due_date":       dueDate,
+				"created_at":     row.CreatedAt,
+				"updated_at":     row.UpdatedAt,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			if _, err := c.Writer.WriteString(","); err != nil {
+				return err
+			}
+		}
+		if _, err := c.Writer.Write(feature); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.Writer.WriteString("]}")
+	return err
+}
+
+// запись реестра дефектов в CSV с BOM для корректного открытия в Excel
+func writeDefectsCSV(c *gin.Context, next func() (*defectExportRow, error)) error {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if _, err := c.Writer.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Comma = ';'
+	if err := writer.Write(defectExportHeaders); err != nil {
+		return err
+	}
+
+	formatDate := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("02.01.2006")
+	}
+
+	for {
+		row, err := next()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		if err := writer.Write([]string{
+			strconv.FormatUint(uint64(row.ID), 10),
+			spreadsheetText(row.Title),
+			spreadsheetText(row.Description),
+			spreadsheetText(row.ProjectName),
+			defectStatusLabel(row.Status),
+			defectPriorityLabel(row.Priority),
+			spreadsheetText(row.ReporterName),
+			spreadsheetText(row.AssigneeName),
+			formatDate(row.DueDate),
+			row.CreatedAt.Format("02.01.2006 15:04"),
+			row.UpdatedAt.Format("02.01.2006 15:04"),
+			spreadsheetText(row.LastComment),
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// запись реестра дефектов в XLSX с типизированными ячейками дат
+func writeDefectsXLSX(c *gin.Context, next func() (*defectExportRow, error)) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	const sheet = "Дефекты"
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	headerStyle, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	dateStyle, err := file.NewStyle(&excelize.Style{NumFmt: 14})
+	if err != nil {
+		return err
+	}
+	dateTimeStyle, err := file.NewStyle(&excelize.Style{NumFmt: 22})
+	if err != nil {
+		return err
+	}
+
+	if err := stream.SetColWidth(2, 3, 40); err != nil {
+		return err
+	}
+	if err := stream.SetColWidth(4, 11, 18); err != nil {
+		return err
+	}
+	if err := stream.SetColWidth(12, 12, 50); err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(defectExportHeaders))
+	for i, title := range defectExportHeaders {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: title}
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	for rowIndex := 2; ; rowIndex++ {
+		row, err := next()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+
+		var dueDate interface{}
+		if row.DueDate != nil {
+			dueDate = excelize.Cell{StyleID: dateStyle, Value: *row.DueDate}
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, rowIndex)
+		if err := stream.SetRow(cell, []interface{}{
+			row.ID,
+			row.Title,
+			row.Description,
+			row.ProjectName,
+			defectStatusLabel(row.Status),
+			defectPriorityLabel(row.Priority),
+			row.ReporterName,
+			row.AssigneeName,
+			dueDate,
+			excelize.Cell{StyleID: dateTimeStyle, Value: row.CreatedAt},
+			excelize.Cell{StyleID: dateTimeStyle, Value: row.UpdatedAt},
+			row.LastComment,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Status(http.StatusOK)
+	_, err = file.WriteTo(c.Writer)
+	return err
+}
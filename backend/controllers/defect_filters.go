@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"systemControl_proj/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// разбор даты из параметра запроса (YYYY-MM-DD или RFC3339)
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// применение фильтров списка дефектов из параметров запроса
+func applyDefectFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	// Сначала проверяем параметр из URL пути (для маршрута /projects/:id/defects)
+	projectID := c.Param("id")
+	// Если параметра в пути нет, проверяем query-параметр
+	if projectID == "" {
+		projectID = c.Query("project_id")
+	}
+
+	status := c.Query("status")
+	priority := c.Query("priority")
+	assigneeID := c.Query("assignee_id")
+	reporterID := c.Query("reporter_id")
+
+	// пользователи без роли менеджера видят только дефекты своих проектов
+	query = scopeToUserProjects(c, query, "defects.project_id")
+
+	if projectID != "" {
+		query = query.Where("defects.project_id = ?", projectID)
+	}
+	if status != "" {
+		query = query.Where("defects.status = ?", status)
+	}
+	if priority != "" {
+		query = query.Where("defects.priority = ?", priority)
+	}
+	if assigneeID != "" {
+		query = query.Where("defects.assignee_id = ?", assigneeID)
+	}
+	if reporterID != "" {
+		query = query.Where("defects.reporter_id = ?", reporterID)
+	}
+	// stage_id=none отбирает дефекты без этапа
+	switch stageID := c.Query("stage_id"); stageID {
+	case "":
+	case "none":
+		query = query.Where("defects.stage_id IS NULL")
+	default:
+		if _, err := strconv.ParseUint(stageID, 10, 64); err != nil {
+			return nil, fmt.Errorf("некорректный stage_id")
+		}
+		query = query.Where("defects.stage_id = ?", stageID)
+	}
+	// location_id отбирает дефекты места и всех вложенных в него мест, none — без места
+	switch locationID := c.Query("location_id"); locationID {
+	case "":
+	case "none":
+		query = query.Where("defects.location_id IS NULL")
+	default:
+		if _, err := strconv.ParseUint(locationID, 10, 64); err != nil {
+			return nil, fmt.Errorf("некорректный location_id")
+		}
+		query = query.Where("defects.location_id IN ("+locationSubtreeSQL+")", locationID)
+	}
+
+	// полнотекстовый поиск по названию, описанию и комментариям
+	if search := c.Query("q"); search != "" {
+		query = query.Where("defects.search_vector @@ websearch_to_tsquery('russian', ?)", search)
+	}
+
+	// период создания дефекта
+	if from := c.Query("created_from"); from != "" {
+		t, err := parseDateParam(from)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная дата created_from")
+		}
+		query = query.Where("defects.created_at >= ?", t)
+	}
+	if to := c.Query("created_to"); to != "" {
+		t, err := parseDateParam(to)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная дата created_to")
+		}
+		// дата без времени включает весь день
+		if len(to) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1)
+		}
+		query = query.Where("defects.created_at < ?", t)
+	}
+
+	// прямоугольник и радиус по GPS-координатам дефекта
+	query, err := applyGeoFilters(c, query, "defects.latitude", "defects.longitude")
+	if err != nil {
+		return nil, err
+	}
+
+	// просроченные открытые дефекты (срок устранения истёк, дефект не закрыт и не отменён)
+	overdue := "(defects.due_date < ? AND defects.status NOT IN ?)"
+	switch c.Query("overdue") {
+	case "":
+	case "true":
+		query = query.Where(overdue, time.Now(), models.ClosedDefectStatuses)
+	case "false":
+		query = query.Where(overdue+" IS NOT TRUE", time.Now(), models.ClosedDefectStatuses)
+	default:
+		return nil, fmt.Errorf("параметр overdue принимает значения true или false")
+	}
+
+	// срок устранения раньше указанной даты
+	if dueBefore := c.Query("due_before"); dueBefore != "" {
+		t, err := parseDateParam(dueBefore)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная дата due_before")
+		}
+		query = query.Where("defects.due_date < ?", t)
+	}
+
+	return query, nil
+}
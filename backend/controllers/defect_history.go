@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"strconv"
+	"systemControl_proj/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// строковое представление ID пользователя для истории (0 — не назначен)
+func historyUserID(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// строковое представление необязательной ссылки для истории
+func historyRefID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return historyUserID(*id)
+}
+
+// строковое представление пары координат (метки на плане или GPS) для истории
+func historyPin(x, y *float64) string {
+	if x == nil || y == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*x, 'f', -1, 64) + ";" + strconv.FormatFloat(*y, 'f', -1, 64)
+}
+
+// строковое представление необязательной даты для истории
+func historyTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// сравнение состояний дефекта до и после изменения
+func diffDefect(before, after *models.Defect, userID uint) []models.DefectHistory {
+	var entries []models.DefectHistory
+
+	add := func(field, oldValue, newValue string) {
+		if oldValue == newValue {
+			return
+		}
+		entries = append(entries, models.DefectHistory{
+			DefectID: after.ID,
+			UserID:   userID,
+			Field:    field,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+
+	add("title", before.Title, after.Title)
+	add("description", before.Description, after.Description)
+	add("status", string(before.Status), string(after.Status))
+	add("priority", string(before.Priority), string(after.Priority))
+	add("assignee_id", historyRefID(before.AssigneeID), historyRefID(after.AssigneeID))
+	add("due_date", historyTime(before.DueDate), historyTime(after.DueDate))
+	add("stage_id", historyRefID(before.StageID), historyRefID(after.StageID))
+	add("location_id", historyRefID(before.LocationID), historyRefID(after.LocationID))
+	add("plan_id", historyRefID(before.PlanID), historyRefID(after.PlanID))
+	add("pin", historyPin(before.PinX, before.PinY), historyPin(after.PinX, after.PinY))
+	add("coordinates", historyPin(before.Latitude, before.Longitude), historyPin(after.Latitude, after.Longitude))
+
+	return entries
+}
+
+// сохранение изменений дефекта в истории
+func recordDefectHistory(tx *gorm.DB, before, after *models.Defect, userID uint) error {
+	entries := diffDefect(before, after, userID)
+	if len(entries) == 0 {
+		return nil
+	}
+	return tx.Create(&entries).Error
+}
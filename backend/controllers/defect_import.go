@@ -0,0 +1,454 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"systemControl_proj/models"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// максимальное число строк в импортируемом реестре
+const maxImportRows = 5000
+
+// поля дефекта, доступные для импорта, в порядке вывода
+var defectImportFields = []string{
+	"title", "description", "priority", "assignee", "due_date", "latitude", "longitude",
+}
+
+// названия столбцов, сопоставляемые с полями без явного mapping;
+// совпадают с заголовками выгрузки реестра
+var defectImportAliases = map[string][]string{
+	"title":       {"title", "Название"},
+	"description": {"description", "Описание"},
+	"priority":    {"priority", "Приоритет"},
+	"assignee":    {"assignee", "Исполнитель"},
+	"due_date":    {"due_date", "Срок устранения"},
+	"latitude":    {"latitude", "Широта"},
+	"longitude":   {"longitude", "Долгота"},
+}
+
+// ошибка проверки значения в строке импорта
+type defectImportError struct {
+	Field  string `json:"field"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+// результат проверки и создания одной строки импорта
+type defectImportRow struct {
+	Row      int                 `json:"row"`
+	Title    string              `json:"title"`
+	DefectID uint                `json:"defect_id,omitempty"`
+	Errors   []defectImportError `json:"errors,omitempty"`
+
+	defect   models.Defect
+	assignee *models.User
+}
+
+// импорт дефектов проекта из CSV или XLSX.
+// По умолчанию выполняется пробный прогон (dry_run=true): файл разбирается и
+// каждая строка проверяется без сохранения. С dry_run=false все дефекты
+// создаются в одной транзакции, если ни в одной строке нет ошибок.
+func (dc *DefectController) ImportDefects(c *gin.Context) {
+	userID, _ := contextUser(c)
+
+	var project models.Project
+	if result := dc.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+	if _, ok := requireProjectRole(c, dc.DB, project.ID, defectEditorRoles...); !ok {
+		return
+	}
+
+	maxSize := int64(dc.Config.Storage.MaxUploadSizeMB) << 20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("размер файла превышает %d МБ", dc.Config.Storage.MaxUploadSizeMB)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "файл не передан (ожидается поле file)"})
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultPostForm("dry_run", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "параметр dry_run принимает значения true или false"})
+		return
+	}
+
+	// сопоставление полей дефекта со столбцами файла: {"title": "Наименование", ...}
+	mapping := map[string]string{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный mapping: ожидается JSON-объект {поле: столбец}"})
+			return
+		}
+		for field := range mapping {
+			if _, ok := defectImportAliases[field]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("неизвестное поле %q в mapping", field), "fields": defectImportFields})
+				return
+			}
+		}
+	}
+
+	table, isXLSX, err := readImportTable(fileHeader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(table) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "файл не содержит строк с дефектами"})
+		return
+	}
+	if len(table)-1 > maxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("файл содержит больше %d строк", maxImportRows)})
+		return
+	}
+
+	columns, err := resolveImportColumns(table[0], mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "headers": table[0]})
+		return
+	}
+	mappedColumns := make(map[string]string, len(columns))
+	for field, index := range columns {
+		mappedColumns[field] = table[0][index]
+	}
+
+	rows := make([]*defectImportRow, 0, len(table)-1)
+	assignees := map[string]*defectImportAssignee{}
+	invalid := 0
+	for i, record := range table[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		row := dc.parseImportRow(c, &project, record, columns, table[0], isXLSX, assignees)
+		row.Row = i + 2
+		row.defect.ReporterID = userID
+		if len(row.Errors) > 0 {
+			invalid++
+		}
+		rows = append(rows, row)
+	}
+
+	report := gin.H{
+		"dry_run": dryRun,
+		"total":   len(rows),
+		"valid":   len(rows) - invalid,
+		"invalid": invalid,
+		"columns": mappedColumns,
+		"rows":    rows,
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if invalid > 0 {
+		report["error"] = "импорт не выполнен: часть строк содержит ошибки"
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "файл не содержит строк с дефектами"})
+		return
+	}
+
+	// Создание всех дефектов в одной транзакции
+	err = dc.DB.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			if err := createDefect(tx, &row.defect, row.assignee); err != nil {
+				return err
+			}
+			row.DefectID = row.defect.ID
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при импорте дефектов"})
+		return
+	}
+
+	report["message"] = fmt.Sprintf("импортировано дефектов: %d", len(rows))
+	c.JSON(http.StatusCreated, report)
+}
+
+// найденный по имени или email исполнитель либо ошибка его проверки
+type defectImportAssignee struct {
+	user *models.User
+	err  string
+}
+
+// разбор и проверка одной строки импорта
+func (dc *DefectController) parseImportRow(c *gin.Context, project *models.Project, record []string, columns map[string]int, headers []string, isXLSX bool, assignees map[string]*defectImportAssignee) *defectImportRow {
+	row := &defectImportRow{
+		defect: models.Defect{
+			ProjectID: project.ID,
+			Status:    models.DefectStatusNew,
+			Priority:  models.DefectPriorityMedium,
+		},
+	}
+	value := func(field string) string {
+		index, ok := columns[field]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+	// апостроф перед формулой добавляется только при выгрузке в CSV
+	text := func(field string) string {
+		if isXLSX {
+			return value(field)
+		}
+		return unescapeSpreadsheetText(value(field))
+	}
+	fail := func(field, message string) {
+		column := ""
+		if index, ok := columns[field]; ok {
+			column = headers[index]
+		}
+		row.Errors = append(row.Errors, defectImportError{Field: field, Column: column, Error: message})
+	}
+
+	row.Title = text("title")
+	row.defect.Title = row.Title
+	switch {
+	case row.Title == "":
+		fail("title", "название дефекта не заполнено")
+	case utf8.RuneCountInString(row.Title) > 255:
+		fail("title", "название длиннее 255 символов")
+	}
+	row.defect.Description = text("description")
+
+	if raw := value("priority"); raw != "" {
+		priority, ok := parseImportPriority(raw)
+		if !ok {
+			fail("priority", fmt.Sprintf("неизвестный приоритет %q", raw))
+		}
+		row.defect.Priority = priority
+	}
+
+	// исполнитель указывается именем пользователя или email
+	if raw := value("assignee"); raw != "" {
+		key := strings.ToLower(raw)
+		resolved, ok := assignees[key]
+		if !ok {
+			resolved = dc.resolveImportAssignee(c, project.ID, key)
+			assignees[key] = resolved
+		}
+		if resolved.err != "" {
+			fail("assignee", resolved.err)
+		} else {
+			row.assignee = resolved.user
+			row.defect.AssigneeID = &resolved.user.ID
+		}
+	}
+
+	if raw := value("due_date"); raw != "" {
+		dueDate, err := parseImportDate(raw, isXLSX)
+		if err != nil {
+			fail("due_date", fmt.Sprintf("некорректная дата %q", raw))
+		}
+		row.defect.DueDate = &dueDate
+	}
+
+	latitude, latErr := parseImportFloat(value("latitude"))
+	if latErr != nil {
+		fail("latitude", "широта должна быть числом")
+	}
+	longitude, lngErr := parseImportFloat(value("longitude"))
+	if lngErr != nil {
+		fail("longitude", "долгота должна быть числом")
+	}
+	if latErr == nil && lngErr == nil {
+		if err := validateCoordinates(latitude, longitude); err != nil {
+			fail("latitude", err.Error())
+		}
+		row.defect.Latitude, row.defect.Longitude = latitude, longitude
+	}
+
+	return row
+}
+
+// поиск исполнителя по имени пользователя или email и проверка по тем же
+// правилам, что и при создании дефекта
+func (dc *DefectController) resolveImportAssignee(c *gin.Context, projectID uint, login string) *defectImportAssignee {
+	var user models.User
+	if result := dc.DB.Where("LOWER(username) = ? OR LOWER(email) = ?", login, login).First(&user); result.Error != nil {
+		return &defectImportAssignee{err: fmt.Sprintf("пользователь %q не найден", login)}
+	}
+	assignee, assigneeErr := dc.checkDefectAssignee(c, projectID, user.ID)
+	if assigneeErr != nil {
+		return &defectImportAssignee{err: assigneeErr.Error()}
+	}
+	return &defectImportAssignee{user: assignee}
+}
+
+// чтение таблицы из CSV или XLSX (первый лист); формат определяется
+// по расширению файла, а при его отсутствии — по содержимому
+func readImportTable(fileHeader *multipart.FileHeader) ([][]string, bool, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, false, errors.New("ошибка чтения файла")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, false, errors.New("ошибка чтения файла")
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	isXLSX := ext == ".xlsx" || (ext != ".csv" && ext != ".txt" && bytes.HasPrefix(data, []byte("PK\x03\x04")))
+
+	if isXLSX {
+		book, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, true, errors.New("файл не является корректным XLSX")
+		}
+		defer book.Close()
+		sheets := book.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, true, errors.New("файл XLSX не содержит листов")
+		}
+		// необработанные значения: даты приходят серийными номерами Excel
+		rows, err := book.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, true, errors.New("ошибка чтения листа XLSX")
+		}
+		return rows, true, nil
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	if !utf8.Valid(data) {
+		return nil, false, errors.New("файл CSV должен быть в кодировке UTF-8")
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectCSVDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, false, fmt.Errorf("ошибка разбора CSV: %v", err)
+	}
+	return rows, false, nil
+}
+
+// разделитель CSV по первой строке: точка с запятой (как в выгрузке), запятая или табуляция
+func detectCSVDelimiter(data []byte) rune {
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+	delimiter, best := ';', bytes.Count(line, []byte(";"))
+	for _, candidate := range []rune{',', '\t'} {
+		if n := bytes.Count(line, []byte(string(candidate))); n > best {
+			delimiter, best = candidate, n
+		}
+	}
+	return delimiter
+}
+
+// сопоставление полей дефекта с номерами столбцов по заголовку таблицы
+func resolveImportColumns(headers []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(headers))
+	for i, header := range headers {
+		key := strings.ToLower(strings.TrimSpace(header))
+		if _, exists := index[key]; !exists && key != "" {
+			index[key] = i
+		}
+	}
+
+	columns := map[string]int{}
+	for _, field := range defectImportFields {
+		if column, ok := mapping[field]; ok {
+			if column == "" {
+				continue
+			}
+			i, found := index[strings.ToLower(strings.TrimSpace(column))]
+			if !found {
+				return nil, fmt.Errorf("столбец %q для поля %s не найден", column, field)
+			}
+			columns[field] = i
+			continue
+		}
+		for _, alias := range defectImportAliases[field] {
+			if i, found := index[strings.ToLower(alias)]; found {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("не найден столбец с названием дефекта (укажите его в mapping как title)")
+	}
+	return columns, nil
+}
+
+// пустая строка таблицы пропускается
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// приоритет по коду (high) или названию из выгрузки (Высокий)
+func parseImportPriority(value string) (models.DefectPriority, bool) {
+	for priority, label := range defectPriorityLabels {
+		if strings.EqualFold(value, string(priority)) || strings.EqualFold(value, label) {
+			return priority, true
+		}
+	}
+	return "", false
+}
+
+// дата срока устранения: YYYY-MM-DD, RFC 3339, ДД.ММ.ГГГГ или серийный номер даты Excel
+func parseImportDate(value string, isXLSX bool) (time.Time, error) {
+	if t, err := parseDateParam(value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("02.01.2006", value); err == nil {
+		return t, nil
+	}
+	if isXLSX {
+		if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+			return excelize.ExcelDateToTime(serial, false)
+		}
+	}
+	return time.Time{}, errors.New("некорректная дата")
+}
+
+// число с точкой или запятой в качестве десятичного разделителя; пустое значение — nil
+func parseImportFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"reflect"
+	"systemControl_proj/models"
+	"testing"
+	"time"
+)
+
+func TestResolveImportColumns(t *testing.T) {
+	exportHeaders := []string{"ID", "Название", "Описание", "Статус", "Приоритет", "Исполнитель", "Срок устранения", "Широта", "Долгота"}
+
+	tests := []struct {
+		name    string
+		headers []string
+		mapping map[string]string
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name:    "заголовки выгрузки реестра",
+			headers: exportHeaders,
+			want: map[string]int{
+				"title": 1, "description": 2, "priority": 4, "assignee": 5,
+				"due_date": 6, "latitude": 7, "longitude": 8,
+			},
+		},
+		{
+			name:    "английские имена полей без учета регистра и пробелов",
+			headers: []string{" Title ", "PRIORITY", "due_date"},
+			want:    map[string]int{"title": 0, "priority": 1, "due_date": 2},
+		},
+		{
+			name:    "явное сопоставление важнее заголовков выгрузки",
+			headers: []string{"Название", "Краткое описание", "Ответственный"},
+			mapping: map[string]string{"title": "краткое описание", "assignee": "Ответственный"},
+			want:    map[string]int{"title": 1, "assignee": 2},
+		},
+		{
+			name:    "пустое значение в mapping отключает поле",
+			headers: []string{"Название", "Приоритет"},
+			mapping: map[string]string{"priority": ""},
+			want:    map[string]int{"title": 0},
+		},
+		{
+			name:    "повторяющийся заголовок берется первым",
+			headers: []string{"Название", "Название"},
+			want:    map[string]int{"title": 0},
+		},
+		{
+			name:    "столбец из mapping не найден",
+			headers: []string{"Название"},
+			mapping: map[string]string{"assignee": "Ответственный"},
+			wantErr: true,
+		},
+		{
+			name:    "нет столбца с названием",
+			headers: []string{"Описание", "Приоритет"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveImportColumns(tt.headers, tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("столбцы %v, ожидались %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want rune
+	}{
+		{"точка с запятой", "Название;Описание;Приоритет\nТрещина;Стена, секция 2;high\n", ';'},
+		{"запятая", "title,description,priority\nТрещина;скол,стена,high\n", ','},
+		{"табуляция", "title\tdescription\tpriority\n", '\t'},
+		{"один столбец", "Название\nТрещина\n", ';'},
+		{"без перевода строки", "title,priority", ','},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectCSVDelimiter([]byte(tt.data)); got != tt.want {
+				t.Errorf("разделитель %q, ожидался %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBlankRecord(t *testing.T) {
+	tests := []struct {
+		record []string
+		want   bool
+	}{
+		{[]string{}, true},
+		{[]string{"", " ", "\t"}, true},
+		{[]string{"", "Трещина"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := isBlankRecord(tt.record); got != tt.want {
+			t.Errorf("isBlankRecord(%q) = %v, ожидалось %v", tt.record, got, tt.want)
+		}
+	}
+}
+
+func TestParseImportPriority(t *testing.T) {
+	tests := []struct {
+		value string
+		want  models.DefectPriority
+		ok    bool
+	}{
+		{"high", models.DefectPriorityHigh, true},
+		{"LOW", models.DefectPriorityLow, true},
+		{"Средний", models.DefectPriorityMedium, true},
+		{"высокий", models.DefectPriorityHigh, true},
+		{"срочный", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseImportPriority(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseImportPriority(%q) = %q, %v, ожидалось %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		isXLSX  bool
+		want    string
+		wantErr bool
+	}{
+		{"ISO", "2026-03-01", false, "2026-03-01", false},
+		{"RFC 3339", "2026-03-01T10:00:00Z", false, "2026-03-01", false},
+		{"формат выгрузки", "01.03.2026", false, "2026-03-01", false},
+		{"серийный номер Excel в XLSX", "46082", true, "2026-03-01", false},
+		{"серийный номер в CSV не принимается", "46082", false, "", true},
+		{"некорректная дата", "1 марта", false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportDate(tt.value, tt.isXLSX)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Format(time.DateOnly) != tt.want {
+				t.Errorf("дата %s, ожидалась %s", got.Format(time.DateOnly), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportFloat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *float64
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"55.75", floatPtr(55.75), false},
+		{"55,75", floatPtr(55.75), false},
+		{"-37.6", floatPtr(-37.6), false},
+		{"север", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseImportFloat(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseImportFloat(%q): ошибка %v, ожидалась ошибка: %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !equalFloatPtr(got, tt.want) {
+			t.Errorf("parseImportFloat(%q) = %v, ожидалось %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestUnescapeSpreadsheetText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"'=SUM(A1:A2)", "=SUM(A1:A2)"},
+		{"'-10 мм", "-10 мм"},
+		{"'обычный текст", "'обычный текст"},
+		{"'", "'"},
+		{"Трещина", "Трещина"},
+	}
+
+	for _, tt := range tests {
+		if got := unescapeSpreadsheetText(tt.value); got != tt.want {
+			t.Errorf("unescapeSpreadsheetText(%q) = %q, ожидалось %q", tt.value, got, tt.want)
+		}
+		if got := unescapeSpreadsheetText(spreadsheetText(tt.want)); got != tt.want {
+			t.Errorf("выгрузка и импорт %q дают %q", tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"systemControl_proj/models"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// поля дефекта, изменяемые через PATCH
+var defectPatchFields = []string{
+	"title", "description", "status", "priority", "assignee_id", "due_date",
+	"stage_id", "location_id", "plan_id", "pin_x", "pin_y", "latitude", "longitude",
+}
+
+// частичное обновление дефекта в формате JSON Merge Patch (RFC 7396);
+// в отличие от PUT, null очищает необязательные поля
+func (dc *DefectController) PatchDefect(c *gin.Context) {
+	defectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID дефекта"})
+		return
+	}
+
+	patch, status, err := readMergePatch(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var defect models.Defect
+	if result := dc.DB.First(&defect, defectID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "дефект не найден"})
+		return
+	}
+	if _, ok := requireProjectRole(c, dc.DB, defect.ProjectID, defectEditorRoles...); !ok {
+		return
+	}
+	if !ifMatchVersion(c, defect.Version) {
+		dc.respondDefectConflict(c, defect.ID)
+		return
+	}
+	before := defect
+
+	if errs := dc.applyDefectPatch(c, &defect, patch); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	userID, _ := contextUser(c)
+	err = dc.DB.Transaction(func(tx *gorm.DB) error {
+		return saveDefectChanges(tx, &before, &defect, userID)
+	})
+	if errors.Is(err, errVersionConflict) {
+		dc.respondDefectConflict(c, defect.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении дефекта"})
+		return
+	}
+
+	c.Header("ETag", versionETag(defect.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "дефект успешно обновлен",
+		"defect":  defect,
+	})
+}
+
+// применение патча к дефекту с проверкой всех переданных полей;
+// дефект изменяется только в памяти, ошибки собираются по полям
+func (dc *DefectController) applyDefectPatch(c *gin.Context, defect *models.Defect, patch mergePatch) fieldErrors {
+	errs := fieldErrors{}
+	patch.checkFields(defectPatchFields, errs)
+
+	var title *string
+	if patch.decode("title", &title, errs) {
+		switch {
+		case title == nil || strings.TrimSpace(*title) == "":
+			errs["title"] = "название дефекта не может быть пустым"
+		case utf8.RuneCountInString(*title) > 255:
+			errs["title"] = "название длиннее 255 символов"
+		default:
+			defect.Title = *title
+		}
+	}
+
+	var description *string
+	if patch.decode("description", &description, errs) {
+		defect.Description = ""
+		if description != nil {
+			defect.Description = *description
+		}
+	}
+
+	var status *models.DefectStatus
+	if patch.decode("status", &status, errs) {
+		role, _ := projectRole(c, dc.DB, defect.ProjectID)
+		switch {
+		case status == nil:
+			errs["status"] = "статус дефекта не может быть пустым"
+		case *status == defect.Status:
+		case !status.IsValid():
+			errs["status"] = "недопустимый статус дефекта"
+		case !models.CanTransitionDefect(defect.Status, *status, role):
+			allowed := []string{}
+			for _, s := range models.AllowedDefectTransitions(defect.Status, role) {
+				allowed = append(allowed, string(s))
+			}
+			errs["status"] = fmt.Sprintf("недопустимый переход статуса %s → %s (допустимые: %s)", defect.Status, *status, strings.Join(allowed, ", "))
+		default:
+			defect.Status = *status
+		}
+	}
+
+	var priority *models.DefectPriority
+	if patch.decode("priority", &priority, errs) {
+		switch {
+		case priority == nil:
+			errs["priority"] = "приоритет дефекта не может быть пустым"
+		case !priority.IsValid():
+			errs["priority"] = "недопустимый приоритет дефекта"
+		default:
+			defect.Priority = *priority
+		}
+	}
+
+	// null снимает исполнителя; прежний исполнитель повторно не проверяется
+	var assigneeID *uint
+	if patch.decode("assignee_id", &assigneeID, errs) {
+		if assigneeID != nil && (defect.AssigneeID == nil || *defect.AssigneeID != *assigneeID) {
+			if _, assigneeErr := dc.checkDefectAssignee(c, defect.ProjectID, *assigneeID); assigneeErr != nil {
+				errs["assignee_id"] = assigneeErr.Error()
+			} else {
+				defect.AssigneeID = assigneeID
+			}
+		} else if assigneeID == nil {
+			defect.AssigneeID = nil
+		}
+	}
+
+	if dueDate, ok := patch.decodeDate("due_date", errs); ok {
+		defect.DueDate = dueDate
+	}
+
+	var stageID *uint
+	if patch.decode("stage_id", &stageID, errs) {
+		if stageID != nil && !stageBelongsToProject(dc.DB, *stageID, defect.ProjectID) {
+			errs["stage_id"] = "указанный этап не найден в проекте"
+		} else {
+			defect.StageID = stageID
+		}
+	}
+
+	var locationID *uint
+	if patch.decode("location_id", &locationID, errs) {
+		if locationID != nil && !locationBelongsToProject(dc.DB, *locationID, defect.ProjectID) {
+			errs["location_id"] = "указанное место не найдено в проекте"
+		} else {
+			defect.LocationID = locationID
+		}
+	}
+
+	dc.applyPinPatch(defect, patch, errs)
+	applyCoordinatesPatch(&defect.Latitude, &defect.Longitude, patch, errs)
+
+	return errs
+}
+
+// метка на плане: plan_id: null снимает метку, недостающие значения берутся из текущей метки
+func (dc *DefectController) applyPinPatch(defect *models.Defect, patch mergePatch, errs fieldErrors) {
+	var planID *uint
+	var pinX, pinY *float64
+	planSet := patch.decode("plan_id", &planID, errs)
+	xSet := patch.decode("pin_x", &pinX, errs)
+	ySet := patch.decode("pin_y", &pinY, errs)
+	if !planSet && !xSet && !ySet {
+		// при смене места метка должна остаться на плане этого места
+		if _, locationSet := patch["location_id"]; locationSet && defect.PlanID != nil && errs["location_id"] == "" {
+			if _, err := validateDefectPin(dc.DB, defect.ProjectID, defect.LocationID, *defect.PlanID, defect.PinX, defect.PinY); err != nil {
+				errs["location_id"] = err.Error()
+			}
+		}
+		return
+	}
+
+	if planSet && planID == nil {
+		if pinX != nil || pinY != nil {
+			errs["plan_id"] = "для метки необходимо указать план plan_id"
+			return
+		}
+		defect.PlanID, defect.PinX, defect.PinY = nil, nil, nil
+		return
+	}
+
+	plan, x, y := defect.PlanID, defect.PinX, defect.PinY
+	if planSet {
+		plan = planID
+	}
+	if xSet {
+		x = pinX
+	}
+	if ySet {
+		y = pinY
+	}
+
+	for field, value := range map[string]*float64{"pin_x": x, "pin_y": y} {
+		switch {
+		case value == nil:
+			errs[field] = "для метки на плане необходимо указать pin_x и pin_y"
+		case *value < 0 || *value > 1:
+			errs[field] = "координата метки должна быть в диапазоне от 0 до 1"
+		}
+	}
+	if plan == nil {
+		errs["plan_id"] = "для метки необходимо указать план plan_id"
+	}
+	if errs["pin_x"] != "" || errs["pin_y"] != "" || errs["plan_id"] != "" {
+		return
+	}
+	if _, err := validateDefectPin(dc.DB, defect.ProjectID, defect.LocationID, *plan, x, y); err != nil {
+		errs["plan_id"] = err.Error()
+		return
+	}
+	defect.PlanID, defect.PinX, defect.PinY = plan, x, y
+}
+
+// GPS-координаты: широта и долгота задаются или очищаются вместе
+func applyCoordinatesPatch(latitude, longitude **float64, patch mergePatch, errs fieldErrors) {
+	var lat, lng *float64
+	latSet := patch.decode("latitude", &lat, errs)
+	lngSet := patch.decode("longitude", &lng, errs)
+	if !latSet && !lngSet {
+		return
+	}
+
+	newLat, newLng := *latitude, *longitude
+	if latSet {
+		newLat = lat
+	}
+	if lngSet {
+		newLng = lng
+	}
+	if err := validateCoordinates(newLat, newLng); err != nil {
+		// ошибка относится к долготе, если широта не передана или допустима
+		field := "latitude"
+		if !latSet || (newLat != nil && newLng != nil && *newLat >= -90 && *newLat <= 90) {
+			field = "longitude"
+		}
+		errs[field] = err.Error()
+		return
+	}
+	*latitude, *longitude = newLat, newLng
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"systemControl_proj/models"
+	"testing"
+	"time"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func uintPtr(v uint) *uint { return &v }
+
+// дефект с заполненными необязательными полями
+func patchTestDefect() models.Defect {
+	dueDate := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	return models.Defect{
+		ID:          1,
+		Title:       "Трещина в стяжке",
+		Description: "Секция 2, этаж 3",
+		ProjectID:   1,
+		Status:      models.DefectStatusNew,
+		Priority:    models.DefectPriorityMedium,
+		DueDate:     &dueDate,
+		PlanID:      uintPtr(7),
+		PinX:        floatPtr(0.5),
+		PinY:        floatPtr(0.5),
+		Latitude:    floatPtr(55.75),
+		Longitude:   floatPtr(37.62),
+	}
+}
+
+// разбор тела патча для тестов
+func testPatch(t *testing.T, body string) mergePatch {
+	t.Helper()
+	var patch mergePatch
+	if err := json.Unmarshal([]byte(body), &patch); err != nil {
+		t.Fatalf("некорректное тело патча %s: %v", body, err)
+	}
+	return patch
+}
+
+// отсортированный список полей с ошибками
+func errorFields(errs fieldErrors) []string {
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func sameFields(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	sort.Strings(want)
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestApplyDefectPatchNullVersusAbsent(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		errs   []string
+		verify func(t *testing.T, before, after models.Defect)
+	}{
+		{
+			name: "пустой патч ничего не меняет",
+			body: `{}`,
+			verify: func(t *testing.T, before, after models.Defect) {
+				if after.Description != before.Description || after.DueDate != before.DueDate || after.PlanID != before.PlanID {
+					t.Error("дефект изменен пустым патчем")
+				}
+			},
+		},
+		{
+			name: "null очищает описание, срок не затрагивается",
+			body: `{"description": null}`,
+			verify: func(t *testing.T, before, after models.Defect) {
+				if after.Description != "" {
+					t.Errorf("описание %q, ожидалась пустая строка", after.Description)
+				}
+				if after.DueDate != before.DueDate {
+					t.Error("срок изменен, хотя не передавался")
+				}
+			},
+		},
+		{
+			name: "null очищает срок, описание не затрагивается",
+			body: `{"due_date": null}`,
+			verify: func(t *testing.T, before, after models.Defect) {
+				if after.DueDate != nil {
+					t.Errorf("срок %v, ожидался null", after.DueDate)
+				}
+				if after.Description != before.Description {
+					t.Error("описание изменено, хотя не передавалось")
+				}
+			},
+		},
+		{
+			name: "новый срок",
+			body: `{"due_date": "2026-04-15"}`,
+			verify: func(t *testing.T, before, after models.Defect) {
+				if after.DueDate == nil || after.DueDate.Format("2006-01-02") != "2026-04-15" {
+					t.Errorf("срок %v, ожидалось 2026-04-15", after.DueDate)
+				}
+			},
+		},
+		{
+			name: "null для обязательного названия",
+			body: `{"title": null}`,
+			errs: []string{"title"},
+			verify: func(t *testing.T, before, after models.Defect) {
+				if after.Title != before.Title {
+					t.Error("название изменено при ошибке")
+				}
+			},
+		},
+		{
+			name: "null для обязательного приоритета",
+			body: `{"priority": null}`,
+			errs: []string{"priority"},
+		},
+		{
+			name: "недопустимый приоритет",
+			body: `{"priority": "urgent"}`,
+			errs: []string{"priority"},
+		},
+		{
+			name: "ошибки собираются по всем полям",
+			body: `{"title": "", "priority": "urgent", "due_date": "завтра"}`,
+			errs: []string{"due_date", "priority", "title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(http.MethodPatch, "/defects/1", "application/merge-patch+json", tt.body)
+			dc := &DefectController{}
+			before := patchTestDefect()
+			defect := before
+
+			errs := dc.applyDefectPatch(c, &defect, testPatch(t, tt.body))
+			if got := errorFields(errs); !sameFields(got, tt.errs...) {
+				t.Fatalf("ошибки в полях %v, ожидались %v (%v)", got, tt.errs, errs)
+			}
+			if tt.verify != nil {
+				tt.verify(t, before, defect)
+			}
+		})
+	}
+}
+
+func TestPatchDefectUnknownFields(t *testing.T) {
+	body := `{"title": "Трещина", "reporter_id": 5, "project_id": 2}`
+	c, w := newTestContext(http.MethodPatch, "/defects/1", "application/merge-patch+json", body)
+
+	patch, status, err := readMergePatch(c)
+	if err != nil {
+		t.Fatalf("ошибка чтения патча (статус %d): %v", status, err)
+	}
+	defect := patchTestDefect()
+	errs := (&DefectController{}).applyDefectPatch(c, &defect, patch)
+	if got := errorFields(errs); !sameFields(got, "project_id", "reporter_id") {
+		t.Fatalf("ошибки в полях %v, ожидались project_id и reporter_id", got)
+	}
+
+	respondFieldErrors(c, errs)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("статус %d, ожидался %d", w.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestApplyPinPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pinned  bool
+		body    string
+		errs    []string
+		cleared bool
+	}{
+		{"plan_id: null снимает метку", true, `{"plan_id": null}`, nil, true},
+		{"plan_id: null вместе с null-координатами", true, `{"plan_id": null, "pin_x": null, "pin_y": null}`, nil, true},
+		{"plan_id: null вместе с координатами", true, `{"plan_id": null, "pin_x": 0.2, "pin_y": 0.3}`, []string{"plan_id"}, false},
+		{"plan_id: null и одна координата", true, `{"plan_id": null, "pin_y": 0.3}`, []string{"plan_id"}, false},
+		{"координата вне диапазона", true, `{"pin_x": 1.5}`, []string{"pin_x"}, false},
+		{"null для координаты при сохранении плана", true, `{"pin_y": null}`, []string{"pin_y"}, false},
+		{"координаты без плана", false, `{"pin_x": 0.2, "pin_y": 0.3}`, []string{"plan_id"}, false},
+		{"план без координат", false, `{"plan_id": 3}`, []string{"pin_x", "pin_y"}, false},
+		{"поля метки не переданы", true, `{"title": "Трещина"}`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defect := patchTestDefect()
+			if !tt.pinned {
+				defect.PlanID, defect.PinX, defect.PinY = nil, nil, nil
+			}
+			errs := fieldErrors{}
+			(&DefectController{}).applyPinPatch(&defect, testPatch(t, tt.body), errs)
+
+			if got := errorFields(errs); !sameFields(got, tt.errs...) {
+				t.Fatalf("ошибки в полях %v, ожидались %v (%v)", got, tt.errs, errs)
+			}
+			isCleared := defect.PlanID == nil && defect.PinX == nil && defect.PinY == nil
+			if tt.pinned && isCleared != tt.cleared {
+				t.Errorf("метка снята: %v, ожидалось %v", isCleared, tt.cleared)
+			}
+		})
+	}
+}
+
+func TestApplyCoordinatesPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		located  bool
+		body     string
+		errs     []string
+		lat, lng *float64
+	}{
+		{"широта и долгота вместе", false, `{"latitude": 59.93, "longitude": 30.31}`, nil, floatPtr(59.93), floatPtr(30.31)},
+		{"только широта без текущих координат", false, `{"latitude": 59.93}`, []string{"latitude"}, nil, nil},
+		{"только долгота без текущих координат", false, `{"longitude": 30.31}`, []string{"longitude"}, nil, nil},
+		{"только широта при текущих координатах", true, `{"latitude": 59.93}`, nil, floatPtr(59.93), floatPtr(37.62)},
+		{"null для обеих координат", true, `{"latitude": null, "longitude": null}`, nil, nil, nil},
+		{"null только для широты", true, `{"latitude": null}`, []string{"latitude"}, floatPtr(55.75), floatPtr(37.62)},
+		{"широта вне диапазона", true, `{"latitude": 91, "longitude": 30.31}`, []string{"latitude"}, floatPtr(55.75), floatPtr(37.62)},
+		{"долгота вне диапазона", true, `{"latitude": 59.93, "longitude": 181}`, []string{"longitude"}, floatPtr(55.75), floatPtr(37.62)},
+		{"координаты не переданы", true, `{"title": "Трещина"}`, nil, floatPtr(55.75), floatPtr(37.62)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defect := patchTestDefect()
+			if !tt.located {
+				defect.Latitude, defect.Longitude = nil, nil
+			}
+			errs := fieldErrors{}
+			applyCoordinatesPatch(&defect.Latitude, &defect.Longitude, testPatch(t, tt.body), errs)
+
+			if got := errorFields(errs); !sameFields(got, tt.errs...) {
+				t.Fatalf("ошибки в полях %v, ожидались %v (%v)", got, tt.errs, errs)
+			}
+			if !equalFloatPtr(defect.Latitude, tt.lat) || !equalFloatPtr(defect.Longitude, tt.lng) {
+				t.Errorf("координаты %v, %v, ожидались %v, %v", defect.Latitude, defect.Longitude, tt.lat, tt.lng)
+			}
+		})
+	}
+}
+
+func equalFloatPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"systemControl_proj/database"
+	"systemControl_proj/middleware"
+	"systemControl_proj/models"
+	"systemControl_proj/realtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// интервал служебных сообщений, удерживающих соединение и перепроверяющих доступ
+const streamHeartbeatInterval = 25 * time.Second
+
+// контроллер потоков событий проектов (Server-Sent Events)
+type EventStreamController struct {
+	DB     *gorm.DB
+	Broker *realtime.Broker
+}
+
+// создание нового экземпляра контроллера потоков событий
+func NewEventStreamController() *EventStreamController {
+	return &EventStreamController{
+		DB:     database.DB,
+		Broker: realtime.Default,
+	}
+}
+
+// запись одного события SSE в ответ
+func writeStreamEvent(c *gin.Context, name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// выдача одноразового билета для подключения к потоку событий проекта через EventSource
+func (ec *EventStreamController) CreateStreamTicket(c *gin.Context) {
+	var project models.Project
+	if result := ec.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, ec.DB, project.ID) {
+		return
+	}
+
+	userID, _ := contextUser(c)
+	expiresAt, _ := c.Get("tokenExpiresAt")
+	tokenExpiresAt, ok := expiresAt.(time.Time)
+	if !ok {
+		tokenExpiresAt = time.Now().Add(middleware.StreamTicketTTL)
+	}
+
+	ticket, err := middleware.IssueStreamTicket(ec.DB, userID, project.ID, c.GetString("sessionID"), tokenExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при выдаче билета потока событий"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"ticket":     ticket,
+		"expires_in": int(middleware.StreamTicketTTL.Seconds()),
+	})
+}
+
+// поток событий проекта: изменения дефектов, комментарии и изменения проекта
+func (ec *EventStreamController) StreamProjectEvents(c *gin.Context) {
+	var project models.Project
+	if result := ec.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, ec.DB, project.ID) {
+		return
+	}
+
+	events, unsubscribe := ec.Broker.Subscribe(project.ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// поток завершается вместе со сроком действия токена доступа;
+	// клиент переподключается с обновленным токеном
+	var expired <-chan time.Time
+	if expiresAt, ok := c.Get("tokenExpiresAt"); ok {
+		timer := time.NewTimer(time.Until(expiresAt.(time.Time)))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	if err := writeStreamEvent(c, "ready", gin.H{"project_id": project.ID}); err != nil {
+		return
+	}
+
+	sessionID := c.GetString("sessionID")
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-expired:
+			writeStreamEvent(c, "token_expired", gin.H{"project_id": project.ID})
+			return
+		case <-heartbeat.C:
+			// отозванная сессия или исключение из проекта закрывают поток
+			if !middleware.IsSessionActive(ec.DB, sessionID) || !hasProjectAccess(c, ec.DB, project.ID) {
+				writeStreamEvent(c, "access_revoked", gin.H{"project_id": project.ID})
+				return
+			}
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event := <-events:
+			if err := writeStreamEvent(c, event.Event, event); err != nil {
+				return
+			}
+		}
+	}
+}
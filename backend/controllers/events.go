@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"encoding/json"
+	"systemControl_proj/models"
+	"systemControl_proj/realtime"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// максимальный размер уведомления NOTIFY (ограничение PostgreSQL — 8000 байт)
+const maxNotifyPayload = 7900
+
+// публикация события в рамках текущей транзакции: уведомление для потоков
+// событий проекта и постановка доставок в очередь для подписанных вебхуков
+func publishEvent(tx *gorm.DB, event models.WebhookEvent, projectID, id uint, data interface{}) error {
+	if err := notifyProjectStream(tx, event, projectID, id, data); err != nil {
+		return err
+	}
+	return enqueueWebhooks(tx, event, data)
+}
+
+// отправка события слушателям канала PostgreSQL; NOTIFY доставляется только
+// после фиксации транзакции, поэтому отмененные изменения не попадают в поток
+func notifyProjectStream(tx *gorm.DB, event models.WebhookEvent, projectID, id uint, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(realtime.Event{Event: string(event), ProjectID: projectID, ID: id, Data: raw})
+	if err != nil {
+		return err
+	}
+	// слишком большие события передаются без данных, клиент запрашивает их по ID
+	if len(payload) > maxNotifyPayload {
+		payload, err = json.Marshal(realtime.Event{Event: string(event), ProjectID: projectID, ID: id})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Exec("SELECT pg_notify(?, ?)", realtime.Channel, string(payload)).Error
+}
+
+// постановка доставок в очередь для вебхуков, подписанных на событие
+func enqueueWebhooks(tx *gorm.DB, event models.WebhookEvent, data interface{}) error {
+	var webhooks []models.Webhook
+	if err := tx.Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		return err
+	}
+
+	var subscribed []models.Webhook
+	for _, webhook := range webhooks {
+		if webhook.Subscribed(event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":       event,
+		"occurred_at": time.Now().UTC(),
+		"data":        data,
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(subscribed))
+	for i, webhook := range subscribed {
+		deliveries[i] = models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        models.DeliveryStatusPending,
+			NextAttemptAt: time.Now(),
+		}
+	}
+	return tx.Create(&deliveries).Error
+}
+
+// публикация события дефекта с данными дефекта и связанных сущностей
+func publishDefectEvent(tx *gorm.DB, event models.WebhookEvent, defectID uint) error {
+	var defect models.Defect
+	if err := tx.Unscoped().Preload("Project").Preload("Stage").Preload("Location").Preload("Reporter").Preload("Assignee").First(&defect, defectID).Error; err != nil {
+		return err
+	}
+	return publishEvent(tx, event, defect.ProjectID, defect.ID, defect)
+}
+
+// публикация событий изменения дефекта; смена статуса публикуется отдельным событием
+func publishDefectChanges(tx *gorm.DB, before, after *models.Defect) error {
+	if err := publishDefectEvent(tx, models.EventDefectUpdated, after.ID); err != nil {
+		return err
+	}
+	if before.Status != after.Status {
+		return publishDefectEvent(tx, models.EventDefectStatusChanged, after.ID)
+	}
+	return nil
+}
+
+// публикация события комментария с данными автора
+func publishCommentEvent(tx *gorm.DB, event models.WebhookEvent, commentID uint) error {
+	var comment models.Comment
+	if err := tx.Unscoped().Preload("User").Preload("Mentions.User").First(&comment, commentID).Error; err != nil {
+		return err
+	}
+	var defect models.Defect
+	if err := tx.Unscoped().Select("id", "project_id").First(&defect, comment.DefectID).Error; err != nil {
+		return err
+	}
+	return publishEvent(tx, event, defect.ProjectID, comment.ID, comment)
+}
+
+// публикация события проекта с данными проекта и менеджера
+func publishProjectEvent(tx *gorm.DB, event models.WebhookEvent, projectID uint) error {
+	var project models.Project
+	if err := tx.Unscoped().Preload("Manager").First(&project, projectID).Error; err != nil {
+		return err
+	}
+	return publishEvent(tx, event, project.ID, project.ID, project)
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// средний радиус Земли в метрах
+const earthRadiusMeters = 6371000.0
+
+// проверка пары координат: обе заданы или обе отсутствуют, значения в допустимых пределах
+func validateCoordinates(lat, lng *float64) error {
+	if (lat == nil) != (lng == nil) {
+		return fmt.Errorf("широта и долгота указываются вместе")
+	}
+	if lat == nil {
+		return nil
+	}
+	if *lat < -90 || *lat > 90 {
+		return fmt.Errorf("широта должна быть в диапазоне от -90 до 90")
+	}
+	if *lng < -180 || *lng > 180 {
+		return fmt.Errorf("долгота должна быть в диапазоне от -180 до 180")
+	}
+	return nil
+}
+
+// разбор списка чисел, разделенных запятой
+func parseFloatList(value string, count int) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != count {
+		return nil, fmt.Errorf("ожидается %d чисел через запятую", count)
+	}
+	numbers := make([]float64, count)
+	for i, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+// применение гео-фильтров из параметров запроса:
+// bbox=min_lng,min_lat,max_lng,max_lat и lat, lng, radius (в метрах);
+// расстояние считается по формуле гаверсинусов средствами PostgreSQL
+func applyGeoFilters(c *gin.Context, query *gorm.DB, latColumn, lngColumn string) (*gorm.DB, error) {
+	if bbox := c.Query("bbox"); bbox != "" {
+		box, err := parseFloatList(bbox, 4)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный bbox (ожидается min_lng,min_lat,max_lng,max_lat)")
+		}
+		minLng, minLat, maxLng, maxLat := box[0], box[1], box[2], box[3]
+		if minLat > maxLat {
+			return nil, fmt.Errorf("некорректный bbox: min_lat больше max_lat")
+		}
+		query = query.Where(latColumn+" BETWEEN ? AND ?", minLat, maxLat)
+		// прямоугольник, пересекающий 180-й меридиан, задается min_lng > max_lng
+		if minLng <= maxLng {
+			query = query.Where(lngColumn+" BETWEEN ? AND ?", minLng, maxLng)
+		} else {
+			query = query.Where("("+lngColumn+" >= ? OR "+lngColumn+" <= ?)", minLng, maxLng)
+		}
+	}
+
+	if c.Query("lat") != "" || c.Query("lng") != "" || c.Query("radius") != "" {
+		lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+		lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+		radius, radiusErr := strconv.ParseFloat(c.Query("radius"), 64)
+		if latErr != nil || lngErr != nil || radiusErr != nil || radius <= 0 {
+			return nil, fmt.Errorf("для поиска по радиусу необходимы lat, lng и положительный radius в метрах")
+		}
+		if err := validateCoordinates(&lat, &lng); err != nil {
+			return nil, err
+		}
+
+		// грубый отбор по широте, затем точное расстояние по дуге большого круга;
+		// из-за ошибок округления аргумент ASIN может чуть превысить 1, поэтому он ограничивается
+		latDelta := radius / earthRadiusMeters * 180 / math.Pi
+		query = query.Where(latColumn+" BETWEEN ? AND ?", lat-latDelta, lat+latDelta)
+		query = query.Where(fmt.Sprintf(
+			"2 * %f * ASIN(LEAST(1, SQRT(POWER(SIN(RADIANS(%s - ?) / 2), 2) + COS(RADIANS(?)) * COS(RADIANS(%s)) * POWER(SIN(RADIANS(%s - ?) / 2), 2)))) <= ?",
+			earthRadiusMeters, latColumn, latColumn, lngColumn,
+		), lat, lat, lng, radius)
+	}
+
+	return query, nil
+}
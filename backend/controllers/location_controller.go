@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"net/http"
+	"systemControl_proj/database"
+	"systemControl_proj/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ID места и всех вложенных в него мест (рекурсивный обход дерева)
+const locationSubtreeSQL = `WITH RECURSIVE subtree AS (
+		SELECT id FROM project_locations WHERE id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT l.id FROM project_locations l JOIN subtree ON l.parent_id = subtree.id WHERE l.deleted_at IS NULL
+	) SELECT id FROM subtree`
+
+// контроллер иерархии мест на объекте
+type LocationController struct {
+	DB *gorm.DB
+}
+
+// создание нового экземпляра контроллера мест
+func NewLocationController() *LocationController {
+	return &LocationController{
+		DB: database.DB,
+	}
+}
+
+// проверка, что место существует и относится к проекту
+func locationBelongsToProject(db *gorm.DB, locationID, projectID uint) bool {
+	var count int64
+	if err := db.Model(&models.ProjectLocation{}).
+		Where("id = ? AND project_id = ?", locationID, projectID).
+		Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// сборка дерева мест из плоского списка, упорядоченного по sort_order
+func buildLocationTree(locations []models.ProjectLocation) []models.ProjectLocation {
+	children := make(map[uint][]models.ProjectLocation)
+	var roots []models.ProjectLocation
+	for _, location := range locations {
+		if location.ParentID == nil {
+			roots = append(roots, location)
+		} else {
+			children[*location.ParentID] = append(children[*location.ParentID], location)
+		}
+	}
+
+	var attach func(nodes []models.ProjectLocation) []models.ProjectLocation
+	attach = func(nodes []models.ProjectLocation) []models.ProjectLocation {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+	return attach(roots)
+}
+
+// поиск места проекта по параметрам маршрута
+func (lc *LocationController) findLocation(c *gin.Context) (*models.ProjectLocation, bool) {
+	var location models.ProjectLocation
+	if result := lc.DB.Where("project_id = ?", c.Param("id")).First(&location, c.Param("location_id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "место не найдено"})
+		return nil, false
+	}
+	return &location, true
+}
+
+// получение мест проекта деревом (или плоским списком при flat=true)
+func (lc *LocationController) GetLocations(c *gin.Context) {
+	var project models.Project
+	if result := lc.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, lc.DB, project.ID) {
+		return
+	}
+
+	var locations []models.ProjectLocation
+	if result := lc.DB.Where("project_id = ?", project.ID).Order("sort_order, id").Find(&locations); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении мест"})
+		return
+	}
+
+	if c.Query("flat") == "true" {
+		c.JSON(http.StatusOK, gin.H{"locations": locations})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"locations": buildLocationTree(locations)})
+}
+
+// получение места с путем от корня дерева
+func (lc *LocationController) GetLocation(c *gin.Context) {
+	location, ok := lc.findLocation(c)
+	if !ok {
+		return
+	}
+	if !requireProjectAccess(c, lc.DB, location.ProjectID) {
+		return
+	}
+
+	var locations []models.ProjectLocation
+	if result := lc.DB.Where("project_id = ?", location.ProjectID).Order("sort_order, id").Find(&locations); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении мест"})
+		return
+	}
+
+	byID := make(map[uint]models.ProjectLocation, len(locations))
+	for _, l := range locations {
+		byID[l.ID] = l
+	}
+	path := []models.ProjectLocation{}
+	for parentID := location.ParentID; parentID != nil; {
+		parent, ok := byID[*parentID]
+		if !ok {
+			break
+		}
+		path = append([]models.ProjectLocation{parent}, path...)
+		parentID = parent.ParentID
+	}
+
+	for _, node := range buildLocationTree(locations) {
+		if found := findLocationNode(node, location.ID); found != nil {
+			location.Children = found.Children
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"location": location,
+		"path":     path,
+	})
+}
+
+// поиск узла в дереве мест
+func findLocationNode(node models.ProjectLocation, id uint) *models.ProjectLocation {
+	if node.ID == id {
+		return &node
+	}
+	for _, child := range node.Children {
+		if found := findLocationNode(child, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// создание места в проекте
+func (lc *LocationController) CreateLocation(c *gin.Context) {
+	var locationCreate models.ProjectLocationCreate
+	if err := c.ShouldBindJSON(&locationCreate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var project models.Project
+	if result := lc.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+
+	location := models.ProjectLocation{
+		ProjectID: project.ID,
+		ParentID:  locationCreate.ParentID,
+		Name:      locationCreate.Name,
+		Kind:      locationCreate.Kind,
+		Order:     locationCreate.Order,
+	}
+	if location.Kind == "" {
+		location.Kind = models.LocationKindOther
+	}
+	if !location.Kind.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "недопустимый тип места"})
+		return
+	}
+	if location.ParentID != nil && !locationBelongsToProject(lc.DB, *location.ParentID, project.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "родительское место не найдено в проекте"})
+		return
+	}
+
+	if result := lc.DB.Create(&location); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении места"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "место успешно создано",
+		"location": location,
+	})
+}
+
+// обновление места: название, тип, порядок или перенос в другую ветку дерева
+func (lc *LocationController) UpdateLocation(c *gin.Context) {
+	var locationUpdate models.ProjectLocationUpdate
+	if err := c.ShouldBindJSON(&locationUpdate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, ok := lc.findLocation(c)
+	if !ok {
+		return
+	}
+
+	if locationUpdate.Name != "" {
+		location.Name = locationUpdate.Name
+	}
+	if locationUpdate.Kind != "" {
+		if !locationUpdate.Kind.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "недопустимый тип места"})
+			return
+		}
+		location.Kind = locationUpdate.Kind
+	}
+	if locationUpdate.Order != nil {
+		location.Order = *locationUpdate.Order
+	}
+	if locationUpdate.ParentID != nil {
+		if *locationUpdate.ParentID == 0 {
+			location.ParentID = nil
+		} else {
+			parentID := *locationUpdate.ParentID
+			if !locationBelongsToProject(lc.DB, parentID, location.ProjectID) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "родительское место не найдено в проекте"})
+				return
+			}
+			// место нельзя перенести внутрь самого себя или своих потомков
+			var inSubtree int64
+			if err := lc.DB.Raw("SELECT COUNT(*) FROM ("+locationSubtreeSQL+") s WHERE s.id = ?", location.ID, parentID).Scan(&inSubtree).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении места"})
+				return
+			}
+			if inSubtree > 0 {
+				c.JSON(http.StatusConflict, gin.H{"error": "место нельзя перенести внутрь самого себя"})
+				return
+			}
+			location.ParentID = &parentID
+		}
+	}
+
+	if result := lc.DB.Save(location); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении места"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "место успешно обновлено",
+		"location": location,
+	})
+}
+
+// удаление места без вложенных мест; дефекты места остаются без привязки
+func (lc *LocationController) DeleteLocation(c *gin.Context) {
+	location, ok := lc.findLocation(c)
+	if !ok {
+		return
+	}
+
+	var children int64
+	if result := lc.DB.Model(&models.ProjectLocation{}).Where("parent_id = ?", location.ID).Count(&children); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении места"})
+		return
+	}
+	if children > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "сначала удалите или перенесите вложенные места"})
+		return
+	}
+
+	// планы места с метками дефектов удаляются отдельно, чтобы не остаться без места
+	var plans int64
+	if result := lc.DB.Model(&models.FloorPlan{}).Where("location_id = ?", location.ID).Count(&plans); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении места"})
+		return
+	}
+	if plans > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "сначала удалите планы этого места"})
+		return
+	}
+
+	userID, _ := contextUser(c)
+	err := lc.DB.Transaction(func(tx *gorm.DB) error {
+		clearLocation := func(defect *models.Defect) { defect.LocationID = nil }
+		if err := unlinkDefects(tx, "location_id", location.ID, map[string]interface{}{"location_id": nil}, clearLocation, userID); err != nil {
+			return err
+		}
+		return tx.Delete(location).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении места"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "место успешно удалено",
+	})
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// тело запроса JSON Merge Patch (RFC 7396): переданные поля заменяют
+// значения записи, null очищает поле, отсутствующие поля не изменяются
+type mergePatch map[string]json.RawMessage
+
+// ошибки проверки по полям запроса: поле — текст ошибки
+type fieldErrors map[string]string
+
+// чтение тела запроса JSON Merge Patch; возвращает HTTP-статус ошибки
+func readMergePatch(c *gin.Context) (mergePatch, int, error) {
+	contentType := c.ContentType()
+	if contentType != "application/merge-patch+json" && contentType != "application/json" {
+		return nil, http.StatusUnsupportedMediaType, errors.New("ожидается тело запроса application/merge-patch+json")
+	}
+
+	var patch mergePatch
+	if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil || patch == nil {
+		return nil, http.StatusBadRequest, errors.New("тело запроса должно быть JSON-объектом")
+	}
+	return patch, 0, nil
+}
+
+// отметка полей, которые нельзя изменить через патч
+func (p mergePatch) checkFields(allowed []string, errs fieldErrors) {
+	known := make(map[string]bool, len(allowed))
+	for _, field := range allowed {
+		known[field] = true
+	}
+	for field := range p {
+		if !known[field] {
+			errs[field] = "поле не поддерживается или не может быть изменено"
+		}
+	}
+}
+
+// разбор значения поля в dest — указатель на указатель, который после разбора
+// равен nil для null. Возвращает true, если поле передано и имеет верный тип.
+func (p mergePatch) decode(field string, dest interface{}, errs fieldErrors) bool {
+	raw, ok := p[field]
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		errs[field] = "неверный тип значения"
+		return false
+	}
+	return true
+}
+
+// разбор даты (YYYY-MM-DD или RFC 3339); nil — передан null
+func (p mergePatch) decodeDate(field string, errs fieldErrors) (*time.Time, bool) {
+	var raw *string
+	if !p.decode(field, &raw, errs) {
+		return nil, false
+	}
+	if raw == nil {
+		return nil, true
+	}
+	t, err := parseDateParam(*raw)
+	if err != nil {
+		errs[field] = "некорректная дата (ожидается YYYY-MM-DD или RFC 3339)"
+		return nil, false
+	}
+	return &t, true
+}
+
+// ответ 422 с ошибками проверки по полям
+func respondFieldErrors(c *gin.Context, errs fieldErrors) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  "изменения не применены: ошибки в полях запроса",
+		"fields": errs,
+	})
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// тестовый контекст запроса с телом и заголовком Content-Type
+func newTestContext(method, target, contentType, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		c.Request.Header.Set("Content-Type", contentType)
+	}
+	return c, w
+}
+
+func TestReadMergePatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		fields      int
+	}{
+		{"merge patch", "application/merge-patch+json", `{"title": "Трещина", "description": null}`, 0, 2},
+		{"json с кодировкой", "application/json; charset=utf-8", `{"title": "Трещина"}`, 0, 1},
+		{"пустой объект", "application/merge-patch+json", `{}`, 0, 0},
+		{"другой тип тела", "text/plain", `{"title": "Трещина"}`, http.StatusUnsupportedMediaType, 0},
+		{"без типа тела", "", `{"title": "Трещина"}`, http.StatusUnsupportedMediaType, 0},
+		{"массив", "application/merge-patch+json", `[{"title": "Трещина"}]`, http.StatusBadRequest, 0},
+		{"null", "application/merge-patch+json", `null`, http.StatusBadRequest, 0},
+		{"некорректный JSON", "application/merge-patch+json", `{"title":`, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(http.MethodPatch, "/defects/1", tt.contentType, tt.body)
+			patch, status, err := readMergePatch(c)
+			if status != tt.status {
+				t.Fatalf("статус %d, ожидался %d (ошибка: %v)", status, tt.status, err)
+			}
+			if tt.status != 0 {
+				if err == nil {
+					t.Fatal("ожидалась ошибка")
+				}
+				return
+			}
+			if len(patch) != tt.fields {
+				t.Errorf("получено полей %d, ожидалось %d", len(patch), tt.fields)
+			}
+		})
+	}
+}
+
+func TestMergePatchDecode(t *testing.T) {
+	patch := mergePatch{
+		"stage_id":    json.RawMessage(`3`),
+		"location_id": json.RawMessage(`null`),
+		"plan_id":     json.RawMessage(`"3"`),
+	}
+
+	tests := []struct {
+		name    string
+		field   string
+		set     bool
+		isNull  bool
+		invalid bool
+	}{
+		{"значение", "stage_id", true, false, false},
+		{"null", "location_id", true, true, false},
+		{"поле отсутствует", "assignee_id", false, false, false},
+		{"неверный тип", "plan_id", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := fieldErrors{}
+			var value *uint
+			if set := patch.decode(tt.field, &value, errs); set != tt.set {
+				t.Fatalf("decode вернул %v, ожидалось %v", set, tt.set)
+			}
+			if tt.set && (value == nil) != tt.isNull {
+				t.Errorf("значение %v, ожидался null: %v", value, tt.isNull)
+			}
+			if _, failed := errs[tt.field]; failed != tt.invalid {
+				t.Errorf("ошибки полей %v, ожидалась ошибка: %v", errs, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestMergePatchDecodeDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		set     bool
+		isNull  bool
+		invalid bool
+	}{
+		{"дата", `"2026-03-01"`, true, false, false},
+		{"RFC 3339", `"2026-03-01T10:00:00Z"`, true, false, false},
+		{"null очищает срок", `null`, true, true, false},
+		{"неверный формат", `"01.03.2026"`, false, false, true},
+		{"число", `20260301`, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := fieldErrors{}
+			patch := mergePatch{"due_date": json.RawMessage(tt.raw)}
+			date, set := patch.decodeDate("due_date", errs)
+			if set != tt.set {
+				t.Fatalf("decodeDate вернул %v, ожидалось %v", set, tt.set)
+			}
+			if set && (date == nil) != tt.isNull {
+				t.Errorf("дата %v, ожидался null: %v", date, tt.isNull)
+			}
+			if _, failed := errs["due_date"]; failed != tt.invalid {
+				t.Errorf("ошибки полей %v, ожидалась ошибка: %v", errs, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestMergePatchCheckFields(t *testing.T) {
+	patch := mergePatch{
+		"title":       json.RawMessage(`"Трещина"`),
+		"reporter_id": json.RawMessage(`5`),
+		"version":     json.RawMessage(`2`),
+	}
+	errs := fieldErrors{}
+	patch.checkFields(defectPatchFields, errs)
+
+	if len(errs) != 2 || errs["reporter_id"] == "" || errs["version"] == "" {
+		t.Errorf("ожидались ошибки для reporter_id и version, получено %v", errs)
+	}
+}
+
+func TestRespondFieldErrors(t *testing.T) {
+	c, w := newTestContext(http.MethodPatch, "/defects/1", "application/merge-patch+json", "")
+	respondFieldErrors(c, fieldErrors{"reporter_id": "поле не поддерживается или не может быть изменено"})
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("статус %d, ожидался %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	var body struct {
+		Error  string            `json:"error"`
+		Fields map[string]string `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("некорректный JSON ответа: %v", err)
+	}
+	if body.Error == "" || body.Fields["reporter_id"] == "" {
+		t.Errorf("неожиданное тело ответа: %s", w.Body.String())
+	}
+}
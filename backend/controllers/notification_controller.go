@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"net/http"
+	"systemControl_proj/database"
+	"systemControl_proj/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// контроллер уведомлений текущего пользователя
+type NotificationController struct {
+	DB *gorm.DB
+}
+
+// создание нового экземпляра контроллера уведомлений
+func NewNotificationController() *NotificationController {
+	return &NotificationController{
+		DB: database.DB,
+	}
+}
+
+// количество непрочитанных уведомлений пользователя
+func (nc *NotificationController) unreadCount(userID uint) (int64, error) {
+	var count int64
+	err := nc.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// получение списка уведомлений текущего пользователя
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	userID, _ := contextUser(c)
+
+	query := nc.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	query = query.Session(&gorm.Session{})
+
+	page, perPage := getPagination(c)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении уведомлений"})
+		return
+	}
+
+	var notifications []models.Notification
+	if result := paginate(query, page, perPage).Preload("Actor").Order("created_at DESC, id DESC").Find(&notifications); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении уведомлений"})
+		return
+	}
+
+	unread, err := nc.unreadCount(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении уведомлений"})
+		return
+	}
+
+	response := pageMeta(total, page, perPage)
+	response["notifications"] = notifications
+	response["unread_count"] = unread
+	c.JSON(http.StatusOK, response)
+}
+
+// получение количества непрочитанных уведомлений
+func (nc *NotificationController) GetUnreadCount(c *gin.Context) {
+	userID, _ := contextUser(c)
+
+	unread, err := nc.unreadCount(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении уведомлений"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"unread_count": unread,
+	})
+}
+
+// отметка уведомления как прочитанного
+func (nc *NotificationController) MarkRead(c *gin.Context) {
+	userID, _ := contextUser(c)
+
+	var notification models.Notification
+	if result := nc.DB.Where("user_id = ?", userID).First(&notification, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "уведомление не найдено"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if result := nc.DB.Model(&notification).Update("read_at", now); result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении уведомления"})
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "уведомление отмечено как прочитанное",
+		"notification": notification,
+	})
+}
+
+// отметка всех уведомлений как прочитанных
+func (nc *NotificationController) MarkAllRead(c *gin.Context) {
+	userID, _ := contextUser(c)
+
+	result := nc.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении уведомлений"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "все уведомления отмечены как прочитанные",
+		"updated": result.RowsAffected,
+	})
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"fmt"
+	"systemControl_proj/models"
+	"systemControl_proj/notify"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// получатели уведомлений о дефекте: исполнитель, автор и менеджер проекта (кроме инициатора)
+func defectRecipients(tx *gorm.DB, defect *models.Defect, actorID uint) ([]uint, error) {
+	candidates := []uint{defect.ReporterID}
+	if defect.AssigneeID != nil {
+		candidates = append(candidates, *defect.AssigneeID)
+	}
+
+	var project models.Project
+	if err := tx.Unscoped().Select("id", "manager_id").First(&project, defect.ProjectID).Error; err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, project.ManagerID)
+
+	return uniqueRecipients(candidates, actorID), nil
+}
+
+// получатели без повторов, пустых идентификаторов и инициатора события
+func uniqueRecipients(candidates []uint, actorID uint) []uint {
+	seen := map[uint]bool{0: true, actorID: true}
+	recipients := []uint{}
+	for _, id := range candidates {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		recipients = append(recipients, id)
+	}
+	return recipients
+}
+
+// создание уведомлений о событии дефекта для всех получателей
+func notifyDefectEvent(tx *gorm.DB, defect *models.Defect, kind models.NotificationType, actorID uint, message string) error {
+	recipients, err := defectRecipients(tx, defect, actorID)
+	if err != nil {
+		return err
+	}
+	return notify.DefectUsers(tx, defect, kind, actorID, recipients, message)
+}
+
+// отображаемое имя пользователя
+func userDisplayName(user *models.User) string {
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return user.Username
+}
+
+// уведомления об изменениях дефекта: назначение, статус и срок устранения
+func notifyDefectChanges(tx *gorm.DB, before, after *models.Defect, actorID uint) error {
+	if after.AssigneeID != nil && (before.AssigneeID == nil || *after.AssigneeID != *before.AssigneeID) {
+		var assignee models.User
+		if err := tx.First(&assignee, *after.AssigneeID).Error; err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Дефект «%s» назначен исполнителю %s", after.Title, userDisplayName(&assignee))
+		if err := notifyDefectEvent(tx, after, models.NotificationDefectAssigned, actorID, message); err != nil {
+			return err
+		}
+	}
+
+	if after.Status != before.Status {
+		message := fmt.Sprintf("Статус дефекта «%s» изменён: %s → %s", after.Title, defectStatusLabel(before.Status), defectStatusLabel(after.Status))
+		if err := notifyDefectEvent(tx, after, models.NotificationDefectStatusChanged, actorID, message); err != nil {
+			return err
+		}
+	}
+
+	if historyTime(after.DueDate) != historyTime(before.DueDate) {
+		message := fmt.Sprintf("Срок устранения дефекта «%s» изменён на %s", after.Title, formatDueDate(after.DueDate))
+		if err := notifyDefectEvent(tx, after, models.NotificationDefectDueDateChanged, actorID, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// срок устранения в виде текста для уведомлений
+func formatDueDate(t *time.Time) string {
+	if t == nil {
+		return "не задан"
+	}
+	return t.Format("02.01.2006")
+}
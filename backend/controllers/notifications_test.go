@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUniqueRecipients(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []uint
+		actorID    uint
+		want       []uint
+	}{
+		{"автор, исполнитель и менеджер", []uint{3, 5, 1}, 9, []uint{3, 5, 1}},
+		{"инициатор не уведомляется", []uint{3, 5, 1}, 5, []uint{3, 1}},
+		{"автор назначил себя исполнителем", []uint{3, 3, 1}, 0, []uint{3, 1}},
+		{"менеджер сам сообщил о дефекте", []uint{1, 5, 1}, 1, []uint{5}},
+		{"пустой идентификатор пропускается", []uint{0, 5}, 9, []uint{5}},
+		{"некого уведомлять", []uint{4, 4}, 4, []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueRecipients(tt.candidates, tt.actorID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("получатели %v, ожидались %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDueDate(t *testing.T) {
+	due := time.Date(2026, 11, 5, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		date *time.Time
+		want string
+	}{
+		{"срок задан", &due, "05.11.2026"},
+		{"срок не задан", nil, "не задан"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDueDate(tt.date); got != tt.want {
+			t.Errorf("%s: %q, ожидалось %q", tt.name, got, tt.want)
+		}
+	}
+}
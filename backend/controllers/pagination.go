@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// получение параметров постраничной выборки из запроса: списки всегда
+// выдаются постранично, по умолчанию по defaultPerPage записей, не больше maxPerPage
+func getPagination(c *gin.Context) (page, perPage int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err = strconv.Atoi(c.Query("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	return page, perPage
+}
+
+// ограничение запроса текущей страницей
+func paginate(query *gorm.DB, page, perPage int) *gorm.DB {
+	return query.Offset((page - 1) * perPage).Limit(perPage)
+}
+
+// метаданные постраничного ответа: общее количество и номер следующей
+// страницы (null на последней странице)
+func pageMeta(total int64, page, perPage int) gin.H {
+	meta := gin.H{
+		"total":     total,
+		"page":      page,
+		"per_page":  perPage,
+		"next_page": nil,
+	}
+	if int64(page*perPage) < total {
+		meta["next_page"] = page + 1
+	}
+	return meta
+}
+
+// применение сортировки из параметра sort (например, "-due_date,priority");
+// допускаются только поля из allowed, где ключ — имя в API, значение — SQL-выражение
+func applySort(c *gin.Context, query *gorm.DB, allowed map[string]string, defaultSort, idColumn string) (*gorm.DB, error) {
+	sort := c.DefaultQuery("sort", defaultSort)
+
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+			field = field[1:]
+		}
+
+		column, ok := allowed[field]
+		if !ok {
+			return nil, fmt.Errorf("недопустимое поле сортировки: %s", field)
+		}
+		query = query.Order(column + " " + direction)
+	}
+
+	// стабильный порядок для постраничной выборки
+	return query.Order(idColumn), nil
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"net/http"
+	"systemControl_proj/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ID и роль текущего пользователя из контекста
+func contextUser(c *gin.Context) (uint, models.Role) {
+	userID, _ := c.Get("userID")
+	userRole, _ := c.Get("role")
+
+	id, _ := userID.(uint)
+	role, _ := userRole.(models.Role)
+	return id, role
+}
+
+// ограничение выборки проектами, в которых состоит пользователь;
+// менеджеры видят все проекты
+func scopeToUserProjects(c *gin.Context, query *gorm.DB, projectColumn string) *gorm.DB {
+	userID, role := contextUser(c)
+	if role == models.RoleManager {
+		return query
+	}
+	return query.Where(projectColumn+" IN (SELECT project_id FROM project_members WHERE user_id = ?)", userID)
+}
+
+// проверка, что пользователь имеет доступ к проекту
+func hasProjectAccess(c *gin.Context, db *gorm.DB, projectID uint) bool {
+	userID, role := contextUser(c)
+	if role == models.RoleManager {
+		return true
+	}
+
+	return isProjectMember(db, projectID, userID)
+}
+
+// проверка, что пользователь состоит в проекте
+func isProjectMember(db *gorm.DB, projectID, userID uint) bool {
+	var count int64
+	if err := db.Model(&models.ProjectMember{}).
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// порядок ролей по объему прав
+var roleRank = map[models.Role]int{
+	models.RoleObserver: 1,
+	models.RoleEngineer: 2,
+	models.RoleManager:  3,
+}
+
+// роли, которым разрешено создавать и изменять дефекты проекта
+var defectEditorRoles = []models.Role{models.RoleManager, models.RoleEngineer}
+
+// действующая роль пользователя в проекте: менеджеры управляют всеми проектами,
+// для остальных действует меньшая из глобальной роли и роли участника проекта
+// (инженер, добавленный в проект наблюдателем, в нем — наблюдатель);
+// false — пользователь не состоит в проекте
+func projectRole(c *gin.Context, db *gorm.DB, projectID uint) (models.Role, bool) {
+	userID, role := contextUser(c)
+	if role == models.RoleManager {
+		return role, true
+	}
+
+	var member models.ProjectMember
+	if err := db.Select("role").Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error; err != nil {
+		return "", false
+	}
+	if roleRank[member.Role] < roleRank[role] {
+		return member.Role, true
+	}
+	return role, true
+}
+
+// проверка, что действующая роль пользователя в проекте входит в roles
+func hasProjectRole(role models.Role, roles []models.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// проверка роли пользователя в проекте с ответом 403; возвращает действующую роль
+func requireProjectRole(c *gin.Context, db *gorm.DB, projectID uint, roles ...models.Role) (models.Role, bool) {
+	role, ok := projectRole(c, db, projectID)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "нет доступа к проекту"})
+		return "", false
+	}
+	if !hasProjectRole(role, roles) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "недостаточно прав в проекте"})
+		return "", false
+	}
+	return role, true
+}
+
+// проверка доступа к проекту с ответом 403 при его отсутствии
+func requireProjectAccess(c *gin.Context, db *gorm.DB, projectID uint) bool {
+	if !hasProjectAccess(c, db, projectID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "нет доступа к проекту"})
+		return false
+	}
+	return true
+}
+
+// добавление пользователя в участники проекта, если он ещё не состоит в нём
+func ensureProjectMember(tx *gorm.DB, projectID, userID uint, role models.Role) error {
+	member := models.ProjectMember{ProjectID: projectID, UserID: userID}
+	return tx.Where(member).Attrs(models.ProjectMember{Role: role}).FirstOrCreate(&member).Error
+}
@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"systemControl_proj/config"
 	"systemControl_proj/database"
 	"systemControl_proj/models"
+	"systemControl_proj/storage"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -12,16 +15,30 @@ import (
 
 // контроллер запросов связанных с проектами
 type ProjectController struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Storage storage.Storage
+	Config  *config.Config
 }
 
 // создание нового экземпляра контроллера проектов
-func NewProjectController() *ProjectController {
+func NewProjectController(config *config.Config) *ProjectController {
 	return &ProjectController{
-		DB: database.DB,
+		DB:      database.DB,
+		Storage: storage.Files,
+		Config:  config,
 	}
 }
 
+// поля, по которым допускается сортировка списка проектов
+var projectSortFields = map[string]string{
+	"id":         "projects.id",
+	"name":       "projects.name",
+	"status":     "projects.status",
+	"start_date": "projects.start_date",
+	"end_date":   "projects.end_date",
+	"created_at": "projects.created_at",
+}
+
 // создание нового проекта
 func (pc *ProjectController) CreateProject(c *gin.Context) {
 	var projectCreate models.ProjectCreate
@@ -31,6 +48,11 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 		return
 	}
 
+	if err := validateCoordinates(projectCreate.Latitude, projectCreate.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Проверка существования менеджера
 	var manager models.User
 	if result := pc.DB.First(&manager, projectCreate.ManagerID); result.Error != nil {
@@ -43,6 +65,8 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 		Name:        projectCreate.Name,
 		Description: projectCreate.Description,
 		Location:    projectCreate.Location,
+		Latitude:    projectCreate.Latitude,
+		Longitude:   projectCreate.Longitude,
 		StartDate:   projectCreate.StartDate,
 		EndDate:     projectCreate.EndDate,
 		Status:      projectCreate.Status,
@@ -54,8 +78,17 @@ func (pc *ProjectController) CreateProject(c *gin.Context) {
 		project.Status = models.ProjectStatusActive
 	}
 
-	// Сохранение проекта в базе данных
-	if result := pc.DB.Create(&project); result.Error != nil {
+	// Сохранение проекта в базе данных; менеджер проекта становится его участником
+	err := pc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+		if err := ensureProjectMember(tx, project.ID, project.ManagerID, models.RoleManager); err != nil {
+			return err
+		}
+		return publishProjectEvent(tx, models.EventProjectCreated, project.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении проекта"})
 		return
 	}
@@ -74,26 +107,47 @@ func (pc *ProjectController) GetAllProjects(c *gin.Context) {
 	status := c.Query("status")
 	managerID := c.Query("manager_id")
 
-	query := pc.DB.Preload("Manager")
+	query := scopeToUserProjects(c, pc.DB.Model(&models.Project{}), "projects.id")
 
 	// Применение фильтров
 	if status != "" {
-		query = query.Where("status = ?", status)
+		query = query.Where("projects.status = ?", status)
 	}
 
 	if managerID != "" {
-		query = query.Where("manager_id = ?", managerID)
+		query = query.Where("projects.manager_id = ?", managerID)
+	}
+
+	query, err := applyGeoFilters(c, query, "projects.latitude", "projects.longitude")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Получение проектов из базы данных
-	if result := query.Find(&projects); result.Error != nil {
+	query = query.Session(&gorm.Session{})
+	page, perPage := getPagination(c)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении проектов"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"projects": projects,
-	})
+	query, err = applySort(c, query, projectSortFields, "-created_at", "projects.id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Получение страницы проектов из базы данных
+	if result := paginate(query, page, perPage).Preload("Manager").Find(&projects); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении проектов"})
+		return
+	}
+
+	response := pageMeta(total, page, perPage)
+	response["projects"] = projects
+	c.JSON(http.StatusOK, response)
 }
 
 // получение конкретного проекта по ID
@@ -101,16 +155,51 @@ func (pc *ProjectController) GetProject(c *gin.Context) {
 	id := c.Param("id")
 
 	var project models.Project
-	if result := pc.DB.Preload("Manager").First(&project, id); result.Error != nil {
+	if result := projectWithRelations(pc.DB).First(&project, id); result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
 		return
 	}
+	if !requireProjectAccess(c, pc.DB, project.ID) {
+		return
+	}
 
+	c.Header("ETag", versionETag(project.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"project": project,
 	})
 }
 
+// сохранение изменений проекта с проверкой версии; менеджер проекта становится его участником
+func saveProjectChanges(tx *gorm.DB, project *models.Project) error {
+	if err := saveVersioned(tx, project, &project.Version); err != nil {
+		return err
+	}
+	if err := ensureProjectMember(tx, project.ID, project.ManagerID, models.RoleManager); err != nil {
+		return err
+	}
+	return publishProjectEvent(tx, models.EventProjectUpdated, project.ID)
+}
+
+// проект с менеджером и этапами (с количеством дефектов на каждом этапе)
+func projectWithRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Manager").Preload("Stages", stagesWithDefectCounts)
+}
+
+// ответ 412 с текущим состоянием проекта, измененного после чтения клиентом
+func (pc *ProjectController) respondProjectConflict(c *gin.Context, projectID uint) {
+	var current models.Project
+	if result := projectWithRelations(pc.DB).First(&current, projectID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+	c.Header("ETag", versionETag(current.Version))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":           "проект был изменен другим пользователем, обновите данные и повторите изменение",
+		"current_version": current.Version,
+		"project":         current,
+	})
+}
+
 // обновление существующего проекта
 func (pc *ProjectController) UpdateProject(c *gin.Context) {
 	id := c.Param("id")
@@ -132,6 +221,11 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
 		return
 	}
+	// Проверка версии из If-Match: проект мог измениться после чтения клиентом
+	if !ifMatchVersion(c, project.Version) {
+		pc.respondProjectConflict(c, project.ID)
+		return
+	}
 
 	// Обновление полей проекта
 	if projectUpdate.Name != "" {
@@ -143,6 +237,13 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 	if projectUpdate.Location != "" {
 		project.Location = projectUpdate.Location
 	}
+	if projectUpdate.Latitude != nil || projectUpdate.Longitude != nil {
+		if err := validateCoordinates(projectUpdate.Latitude, projectUpdate.Longitude); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		project.Latitude, project.Longitude = projectUpdate.Latitude, projectUpdate.Longitude
+	}
 	if !projectUpdate.StartDate.IsZero() {
 		project.StartDate = projectUpdate.StartDate
 	}
@@ -162,11 +263,19 @@ func (pc *ProjectController) UpdateProject(c *gin.Context) {
 		project.ManagerID = projectUpdate.ManagerID
 	}
 
-	if result := pc.DB.Save(&project); result.Error != nil {
+	err = pc.DB.Transaction(func(tx *gorm.DB) error {
+		return saveProjectChanges(tx, &project)
+	})
+	if errors.Is(err, errVersionConflict) {
+		pc.respondProjectConflict(c, project.ID)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении проекта"})
 		return
 	}
 
+	c.Header("ETag", versionETag(project.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"message": "проект успешно обновлен",
 		"project": project,
@@ -185,7 +294,13 @@ func (pc *ProjectController) DeleteProject(c *gin.Context) {
 	}
 
 	// Удаление проекта из базы данных (мягкое удаление с помощью DeletedAt)
-	if result := pc.DB.Delete(&project); result.Error != nil {
+	err := pc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&project).Error; err != nil {
+			return err
+		}
+		return publishProjectEvent(tx, models.EventProjectDeleted, project.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении проекта"})
 		return
 	}
@@ -194,3 +309,140 @@ func (pc *ProjectController) DeleteProject(c *gin.Context) {
 		"message": "проект успешно удален",
 	})
 }
+
+// получение списка участников проекта
+func (pc *ProjectController) GetProjectMembers(c *gin.Context) {
+	var project models.Project
+	if result := pc.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, pc.DB, project.ID) {
+		return
+	}
+
+	var members []models.ProjectMember
+	if result := pc.DB.Where("project_id = ?", project.ID).Preload("User").Order("id").Find(&members); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении участников проекта"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"members": members,
+	})
+}
+
+// добавление участника в проект
+func (pc *ProjectController) AddProjectMember(c *gin.Context) {
+	var memberCreate models.ProjectMemberCreate
+	if err := c.ShouldBindJSON(&memberCreate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var project models.Project
+	if result := pc.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+
+	var user models.User
+	if result := pc.DB.First(&user, memberCreate.UserID); result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "указанный пользователь не найден"})
+		return
+	}
+
+	// по умолчанию роль в проекте совпадает с ролью пользователя в системе
+	role := memberCreate.Role
+	if role == "" {
+		role = user.Role
+	}
+	if !role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "недопустимая роль"})
+		return
+	}
+
+	if isProjectMember(pc.DB, project.ID, user.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "пользователь уже является участником проекта"})
+		return
+	}
+
+	member := models.ProjectMember{
+		ProjectID: project.ID,
+		UserID:    user.ID,
+		Role:      role,
+	}
+	if result := pc.DB.Create(&member); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при добавлении участника проекта"})
+		return
+	}
+
+	member.User = user
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "участник успешно добавлен в проект",
+		"member":  member,
+	})
+}
+
+// изменение роли участника проекта
+func (pc *ProjectController) UpdateProjectMember(c *gin.Context) {
+	var memberUpdate models.ProjectMemberUpdate
+	if err := c.ShouldBindJSON(&memberUpdate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !memberUpdate.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "недопустимая роль"})
+		return
+	}
+
+	var member models.ProjectMember
+	if result := pc.DB.Where("project_id = ? AND user_id = ?", c.Param("id"), c.Param("user_id")).First(&member); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "участник проекта не найден"})
+		return
+	}
+
+	member.Role = memberUpdate.Role
+	if result := pc.DB.Save(&member); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении участника проекта"})
+		return
+	}
+
+	pc.DB.Preload("User").First(&member, member.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "роль участника проекта успешно обновлена",
+		"member":  member,
+	})
+}
+
+// исключение участника из проекта
+func (pc *ProjectController) RemoveProjectMember(c *gin.Context) {
+	var project models.Project
+	if result := pc.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+
+	var member models.ProjectMember
+	if result := pc.DB.Where("project_id = ? AND user_id = ?", project.ID, c.Param("user_id")).First(&member); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "участник проекта не найден"})
+		return
+	}
+
+	// менеджер проекта не может быть исключен, пока он назначен на проект
+	if member.UserID == project.ManagerID {
+		c.JSON(http.StatusConflict, gin.H{"error": "нельзя исключить менеджера проекта"})
+		return
+	}
+
+	if result := pc.DB.Delete(&member); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при исключении участника проекта"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "участник исключен из проекта",
+	})
+}
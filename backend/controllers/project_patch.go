@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"systemControl_proj/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// поля проекта, изменяемые через PATCH
+var projectPatchFields = []string{
+	"name", "description", "location", "latitude", "longitude",
+	"start_date", "end_date", "status", "manager_id",
+}
+
+// частичное обновление проекта в формате JSON Merge Patch (RFC 7396)
+func (pc *ProjectController) PatchProject(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID проекта"})
+		return
+	}
+
+	patch, status, err := readMergePatch(c)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	var project models.Project
+	if result := pc.DB.First(&project, projectID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+	if !ifMatchVersion(c, project.Version) {
+		pc.respondProjectConflict(c, project.ID)
+		return
+	}
+
+	if errs := pc.applyProjectPatch(&project, patch); len(errs) > 0 {
+		respondFieldErrors(c, errs)
+		return
+	}
+
+	err = pc.DB.Transaction(func(tx *gorm.DB) error {
+		return saveProjectChanges(tx, &project)
+	})
+	if errors.Is(err, errVersionConflict) {
+		pc.respondProjectConflict(c, project.ID)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении проекта"})
+		return
+	}
+
+	c.Header("ETag", versionETag(project.Version))
+	c.JSON(http.StatusOK, gin.H{
+		"message": "проект успешно обновлен",
+		"project": project,
+	})
+}
+
+// применение патча к проекту с проверкой всех переданных полей
+func (pc *ProjectController) applyProjectPatch(project *models.Project, patch mergePatch) fieldErrors {
+	errs := fieldErrors{}
+	patch.checkFields(projectPatchFields, errs)
+
+	var name *string
+	if patch.decode("name", &name, errs) {
+		if name == nil || strings.TrimSpace(*name) == "" {
+			errs["name"] = "название проекта не может быть пустым"
+		} else {
+			project.Name = *name
+		}
+	}
+
+	var description *string
+	if patch.decode("description", &description, errs) {
+		project.Description = ""
+		if description != nil {
+			project.Description = *description
+		}
+	}
+
+	var location *string
+	if patch.decode("location", &location, errs) {
+		if location == nil || strings.TrimSpace(*location) == "" {
+			errs["location"] = "адрес объекта не может быть пустым"
+		} else {
+			project.Location = *location
+		}
+	}
+
+	applyCoordinatesPatch(&project.Latitude, &project.Longitude, patch, errs)
+
+	if startDate, ok := patch.decodeDate("start_date", errs); ok {
+		if startDate == nil {
+			errs["start_date"] = "дата начала не может быть пустой"
+		} else {
+			project.StartDate = *startDate
+		}
+	}
+	if endDate, ok := patch.decodeDate("end_date", errs); ok {
+		if endDate == nil {
+			errs["end_date"] = "дата окончания не может быть пустой"
+		} else {
+			project.EndDate = *endDate
+		}
+	}
+
+	var status *models.ProjectStatus
+	if patch.decode("status", &status, errs) {
+		if status == nil || !status.IsValid() {
+			errs["status"] = "недопустимый статус проекта"
+		} else {
+			project.Status = *status
+		}
+	}
+
+	var managerID *uint
+	if patch.decode("manager_id", &managerID, errs) {
+		var manager models.User
+		switch {
+		case managerID == nil:
+			errs["manager_id"] = "менеджер проекта не может быть пустым"
+		case pc.DB.First(&manager, *managerID).Error != nil:
+			errs["manager_id"] = "указанный менеджер не найден"
+		default:
+			project.ManagerID = *managerID
+		}
+	}
+
+	return errs
+}
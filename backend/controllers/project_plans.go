@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"systemControl_proj/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// допустимые форматы файлов планов
+var floorPlanMimeTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"application/pdf": true,
+}
+
+// поиск плана проекта по параметрам маршрута с проверкой доступа к проекту
+func (pc *ProjectController) findFloorPlan(c *gin.Context) (*models.FloorPlan, bool) {
+	var plan models.FloorPlan
+	if result := pc.DB.Where("project_id = ?", c.Param("id")).First(&plan, c.Param("plan_id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "план не найден"})
+		return nil, false
+	}
+	if !requireProjectAccess(c, pc.DB, plan.ProjectID) {
+		return nil, false
+	}
+	return &plan, true
+}
+
+// проверка метки дефекта: план из проекта дефекта, относящийся к месту дефекта
+// (если место указано), и координаты в пределах плана
+func validateDefectPin(db *gorm.DB, projectID uint, locationID *uint, planID uint, x, y *float64) (*models.FloorPlan, error) {
+	if x == nil || y == nil {
+		return nil, fmt.Errorf("для метки на плане необходимо указать pin_x и pin_y")
+	}
+	if *x < 0 || *x > 1 || *y < 0 || *y > 1 {
+		return nil, fmt.Errorf("координаты метки должны быть в диапазоне от 0 до 1")
+	}
+
+	var plan models.FloorPlan
+	if err := db.Where("project_id = ?", projectID).First(&plan, planID).Error; err != nil {
+		return nil, fmt.Errorf("указанный план не найден в проекте")
+	}
+	if locationID != nil && plan.LocationID != *locationID {
+		return nil, fmt.Errorf("указанный план относится к другому месту")
+	}
+	return &plan, nil
+}
+
+// загрузка плана (изображение PNG/JPEG или PDF) для места на объекте
+func (pc *ProjectController) UploadFloorPlan(c *gin.Context) {
+	userID, _ := contextUser(c)
+
+	var location models.ProjectLocation
+	if result := pc.DB.Where("project_id = ?", c.Param("id")).First(&location, c.Param("location_id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "место не найдено"})
+		return
+	}
+
+	maxSize := int64(pc.Config.Storage.MaxUploadSizeMB) << 20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("размер файла превышает %d МБ", pc.Config.Storage.MaxUploadSizeMB)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "файл не передан (ожидается поле file)"})
+		return
+	}
+
+	page := 1
+	if value := c.PostForm("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "номер страницы должен быть положительным числом"})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ошибка чтения файла"})
+		return
+	}
+	defer file.Close()
+
+	// Определение формата по содержимому файла
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ошибка чтения файла"})
+		return
+	}
+	head = head[:n]
+	mimeType := http.DetectContentType(head)
+	if !floorPlanMimeTypes[mimeType] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "план должен быть изображением PNG, JPEG или файлом PDF"})
+		return
+	}
+
+	key, err := newStorageKey(fmt.Sprintf("plans/%d", location.ProjectID), filepath.Ext(fileHeader.Filename))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении файла"})
+		return
+	}
+
+	size, err := pc.Storage.Save(key, io.MultiReader(bytes.NewReader(head), file))
+	if err != nil {
+		log.Printf("Ошибка при сохранении файла плана: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении файла"})
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(fileHeader.Filename), filepath.Ext(fileHeader.Filename))
+	}
+
+	plan := models.FloorPlan{
+		ProjectID:    location.ProjectID,
+		LocationID:   location.ID,
+		Name:         name,
+		FileName:     filepath.Base(fileHeader.Filename),
+		MimeType:     mimeType,
+		Size:         size,
+		Page:         page,
+		StorageKey:   key,
+		UploadedByID: userID,
+	}
+
+	// размеры изображения нужны клиенту для пересчета координат меток
+	if strings.HasPrefix(mimeType, "image/") {
+		if stored, err := pc.Storage.Open(key); err == nil {
+			if cfg, _, err := image.DecodeConfig(stored); err == nil {
+				plan.Width, plan.Height = cfg.Width, cfg.Height
+			}
+			stored.Close()
+		}
+	}
+
+	if result := pc.DB.Create(&plan); result.Error != nil {
+		pc.Storage.Delete(key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении плана"})
+		return
+	}
+
+	pc.DB.Preload("UploadedBy").First(&plan, plan.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "план успешно загружен",
+		"plan":    plan,
+	})
+}
+
+// получение планов места на объекте
+func (pc *ProjectController) GetFloorPlans(c *gin.Context) {
+	var location models.ProjectLocation
+	if result := pc.DB.Where("project_id = ?", c.Param("id")).First(&location, c.Param("location_id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "место не найдено"})
+		return
+	}
+	if !requireProjectAccess(c, pc.DB, location.ProjectID) {
+		return
+	}
+
+	var plans []models.FloorPlan
+	if result := pc.DB.Where("location_id = ?", location.ID).Preload("UploadedBy").Order("created_at, id").Find(&plans); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении планов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plans": plans,
+	})
+}
+
+// получение плана по ID
+func (pc *ProjectController) GetFloorPlan(c *gin.Context) {
+	plan, ok := pc.findFloorPlan(c)
+	if !ok {
+		return
+	}
+
+	pc.DB.Preload("UploadedBy").First(plan, plan.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"plan": plan,
+	})
+}
+
+// получение файла плана
+func (pc *ProjectController) DownloadFloorPlan(c *gin.Context) {
+	plan, ok := pc.findFloorPlan(c)
+	if !ok {
+		return
+	}
+
+	serveStoredFile(c, pc.Storage, plan.StorageKey, plan.MimeType, "")
+}
+
+// удаление плана; метки дефектов на нем снимаются
+func (pc *ProjectController) DeleteFloorPlan(c *gin.Context) {
+	plan, ok := pc.findFloorPlan(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := contextUser(c)
+	err := pc.DB.Transaction(func(tx *gorm.DB) error {
+		clearPin := func(defect *models.Defect) { defect.PlanID, defect.PinX, defect.PinY = nil, nil, nil }
+		columns := map[string]interface{}{"plan_id": nil, "pin_x": nil, "pin_y": nil}
+		if err := unlinkDefects(tx, "plan_id", plan.ID, columns, clearPin, userID); err != nil {
+			return err
+		}
+		return tx.Delete(plan).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении плана"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "план успешно удален",
+	})
+}
+
+// получение меток дефектов на плане с фильтрами по статусу и приоритету
+// (несколько значений через запятую)
+func (pc *ProjectController) GetPlanPins(c *gin.Context) {
+	plan, ok := pc.findFloorPlan(c)
+	if !ok {
+		return
+	}
+
+	query := pc.DB.Model(&models.Defect{}).Where("plan_id = ?", plan.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status IN ?", strings.Split(status, ","))
+	}
+	if priority := c.Query("priority"); priority != "" {
+		query = query.Where("priority IN ?", strings.Split(priority, ","))
+	}
+
+	pins := []models.DefectPin{}
+	if result := query.Select("id AS defect_id, title, status, priority, pin_x AS x, pin_y AS y").Order("id").Scan(&pins); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении меток"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plan": plan,
+		"pins": pins,
+	})
+}
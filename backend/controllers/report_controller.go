@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"net/http"
+	"systemControl_proj/database"
+	"systemControl_proj/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// контроллер аналитических отчётов по дефектам
+type ReportController struct {
+	DB *gorm.DB
+}
+
+// строка агрегированного отчёта
+type ReportRow struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// строка отчёта по неделям создания
+type WeekReportRow struct {
+	Week  time.Time `json:"week"`
+	Count int64     `json:"count"`
+}
+
+// создание нового экземпляра контроллера отчётов
+func NewReportController() *ReportController {
+	return &ReportController{
+		DB: database.DB,
+	}
+}
+
+// базовый запрос к дефектам с фильтрами из параметров
+func (rc *ReportController) defectsQuery(c *gin.Context) (*gorm.DB, bool) {
+	query, err := applyDefectFilters(c, rc.DB.Model(&models.Defect{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return query, true
+}
+
+// отправка агрегированного отчёта с общим количеством
+func respondReport(c *gin.Context, rows []ReportRow) {
+	var total int64
+	for _, row := range rows {
+		total += row.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": rows,
+		"total": total,
+	})
+}
+
+// количество дефектов по статусам
+func (rc *ReportController) DefectsByStatus(c *gin.Context) {
+	query, ok := rc.defectsQuery(c)
+	if !ok {
+		return
+	}
+
+	rows := []ReportRow{}
+	if result := query.Select("defects.status AS key, defects.status AS label, COUNT(*) AS count").
+		Group("defects.status").
+		Order("count DESC").
+		Scan(&rows); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при построении отчёта"})
+		return
+	}
+
+	respondReport(c, rows)
+}
+
+// количество дефектов по приоритетам
+func (rc *ReportController) DefectsByPriority(c *gin.Context) {
+	query, ok := rc.defectsQuery(c)
+	if !ok {
+		return
+	}
+
+	rows := []ReportRow{}
+	if result := query.Select("defects.priority AS key, defects.priority AS label, COUNT(*) AS count").
+		Group("defects.priority").
+		Order("count DESC").
+		Scan(&rows); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при построении отчёта"})
+		return
+	}
+
+	respondReport(c, rows)
+}
+
+// количество дефектов по проектам
+func (rc *ReportController) DefectsByProject(c *gin.Context) {
+	query, ok := rc.defectsQuery(c)
+	if !ok {
+		return
+	}
+
+	rows := []ReportRow{}
+	if result := query.Select("CAST(defects.project_id AS TEXT) AS key, COALESCE(projects.name, '') AS label, COUNT(*) AS count").
+		Joins("LEFT JOIN projects ON projects.id = defects.project_id").
+		Group("defects.project_id, projects.name").
+		Order("count DESC").
+		Scan(&rows); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при построении отчёта"})
+		return
+	}
+
+	respondReport(c, rows)
+}
+
+// количество дефектов по исполнителям
+func (rc *ReportController) DefectsByAssignee(c *gin.Context) {
+	query, ok := rc.defectsQuery(c)
+	if !ok {
+		return
+	}
+
+	rows := []ReportRow{}
+	if result := query.Select("CAST(COALESCE(defects.assignee_id, 0) AS TEXT) AS key, COALESCE(NULLIF(users.full_name, ''), users.username, 'не назначен') AS label, COUNT(*) AS count").
+		Joins("LEFT JOIN users ON users.id = defects.assignee_id").
+		Group("COALESCE(defects.assignee_id, 0), users.full_name, users.username").
+		Order("count DESC").
+		Scan(&rows); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при построении отчёта"})
+		return
+	}
+
+	respondReport(c, rows)
+}
+
+// количество созданных дефектов по неделям
+func (rc *ReportController) DefectsByWeek(c *gin.Context) {
+	query, ok := rc.defectsQuery(c)
+	if !ok {
+		return
+	}
+
+	rows := []WeekReportRow{}
+	if result := query.Select("date_trunc('week', defects.created_at) AS week, COUNT(*) AS count").
+		Group("week").
+		Order("week").
+		Scan(&rows); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при построении отчёта"})
+		return
+	}
+
+	var total int64
+	for _, row := range rows {
+		total += row.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": rows,
+		"total": total,
+	})
+}
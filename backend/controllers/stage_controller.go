@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"net/http"
+	"systemControl_proj/database"
+	"systemControl_proj/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// контроллер этапов проекта
+type StageController struct {
+	DB *gorm.DB
+}
+
+// создание нового экземпляра контроллера этапов
+func NewStageController() *StageController {
+	return &StageController{
+		DB: database.DB,
+	}
+}
+
+// выборка этапов с количеством дефектов на каждом этапе
+func stagesWithDefectCounts(db *gorm.DB) *gorm.DB {
+	return db.Select("project_stages.*, (SELECT COUNT(*) FROM defects WHERE defects.stage_id = project_stages.id AND defects.deleted_at IS NULL) AS defect_count").
+		Order("project_stages.sort_order, project_stages.id")
+}
+
+// проверка, что этап существует и относится к проекту дефекта
+func stageBelongsToProject(db *gorm.DB, stageID, projectID uint) bool {
+	var count int64
+	if err := db.Model(&models.ProjectStage{}).
+		Where("id = ? AND project_id = ?", stageID, projectID).
+		Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// проверка дат этапа: окончание не раньше начала
+func validateStageDates(start, end *time.Time) bool {
+	return start == nil || end == nil || !end.Before(*start)
+}
+
+// поиск этапа проекта по параметрам маршрута
+func (sc *StageController) findStage(c *gin.Context) (*models.ProjectStage, bool) {
+	var stage models.ProjectStage
+	if result := sc.DB.Where("project_id = ?", c.Param("id")).First(&stage, c.Param("stage_id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "этап не найден"})
+		return nil, false
+	}
+	return &stage, true
+}
+
+// получение этапов проекта
+func (sc *StageController) GetStages(c *gin.Context) {
+	var project models.Project
+	if result := sc.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+	if !requireProjectAccess(c, sc.DB, project.ID) {
+		return
+	}
+
+	var stages []models.ProjectStage
+	if result := stagesWithDefectCounts(sc.DB).Where("project_id = ?", project.ID).Find(&stages); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении этапов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stages": stages,
+	})
+}
+
+// создание этапа проекта
+func (sc *StageController) CreateStage(c *gin.Context) {
+	var stageCreate models.ProjectStageCreate
+	if err := c.ShouldBindJSON(&stageCreate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var project models.Project
+	if result := sc.DB.First(&project, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "проект не найден"})
+		return
+	}
+
+	stage := models.ProjectStage{
+		ProjectID:    project.ID,
+		Name:         stageCreate.Name,
+		PlannedStart: stageCreate.PlannedStart,
+		PlannedEnd:   stageCreate.PlannedEnd,
+		ActualStart:  stageCreate.ActualStart,
+		ActualEnd:    stageCreate.ActualEnd,
+		Status:       stageCreate.Status,
+	}
+	if stage.Status == "" {
+		stage.Status = models.StageStatusPlanned
+	}
+	if !stage.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "недопустимый статус этапа"})
+		return
+	}
+	if !validateStageDates(stage.PlannedStart, stage.PlannedEnd) || !validateStageDates(stage.ActualStart, stage.ActualEnd) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "дата окончания этапа раньше даты начала"})
+		return
+	}
+
+	// по умолчанию этап добавляется в конец списка
+	if stageCreate.Order != nil {
+		stage.Order = *stageCreate.Order
+	} else {
+		var maxOrder int
+		sc.DB.Model(&models.ProjectStage{}).Where("project_id = ?", project.ID).
+			Select("COALESCE(MAX(sort_order), 0)").Scan(&maxOrder)
+		stage.Order = maxOrder + 1
+	}
+
+	if result := sc.DB.Create(&stage); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении этапа"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "этап успешно создан",
+		"stage":   stage,
+	})
+}
+
+// обновление этапа проекта
+func (sc *StageController) UpdateStage(c *gin.Context) {
+	var stageUpdate models.ProjectStageUpdate
+	if err := c.ShouldBindJSON(&stageUpdate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stage, ok := sc.findStage(c)
+	if !ok {
+		return
+	}
+
+	if stageUpdate.Name != "" {
+		stage.Name = stageUpdate.Name
+	}
+	if stageUpdate.Order != nil {
+		stage.Order = *stageUpdate.Order
+	}
+	if stageUpdate.PlannedStart != nil {
+		stage.PlannedStart = stageUpdate.PlannedStart
+	}
+	if stageUpdate.PlannedEnd != nil {
+		stage.PlannedEnd = stageUpdate.PlannedEnd
+	}
+	if stageUpdate.ActualStart != nil {
+		stage.ActualStart = stageUpdate.ActualStart
+	}
+	if stageUpdate.ActualEnd != nil {
+		stage.ActualEnd = stageUpdate.ActualEnd
+	}
+	if stageUpdate.Status != "" {
+		if !stageUpdate.Status.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "недопустимый статус этапа"})
+			return
+		}
+		stage.Status = stageUpdate.Status
+	}
+	if !validateStageDates(stage.PlannedStart, stage.PlannedEnd) || !validateStageDates(stage.ActualStart, stage.ActualEnd) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "дата окончания этапа раньше даты начала"})
+		return
+	}
+
+	if result := sc.DB.Save(stage); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении этапа"})
+		return
+	}
+
+	stagesWithDefectCounts(sc.DB).First(stage, stage.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "этап успешно обновлен",
+		"stage":   stage,
+	})
+}
+
+// удаление этапа проекта; дефекты этапа остаются в проекте без этапа
+func (sc *StageController) DeleteStage(c *gin.Context) {
+	stage, ok := sc.findStage(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := contextUser(c)
+	err := sc.DB.Transaction(func(tx *gorm.DB) error {
+		clearStage := func(defect *models.Defect) { defect.StageID = nil }
+		if err := unlinkDefects(tx, "stage_id", stage.ID, map[string]interface{}{"stage_id": nil}, clearStage, userID); err != nil {
+			return err
+		}
+		return tx.Delete(stage).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении этапа"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "этап успешно удален",
+	})
+}
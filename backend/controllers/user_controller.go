@@ -1,16 +1,23 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"systemControl_proj/config"
 	"systemControl_proj/database"
 	"systemControl_proj/middleware"
 	"systemControl_proj/models"
+	"systemControl_proj/notify"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // контроллер запросов связанных с пользователями
@@ -27,6 +34,16 @@ func NewUserController(config *config.Config) *UserController {
 	}
 }
 
+// поля, по которым допускается сортировка списка пользователей
+var userSortFields = map[string]string{
+	"id":         "users.id",
+	"username":   "users.username",
+	"email":      "users.email",
+	"full_name":  "users.full_name",
+	"role":       "users.role",
+	"created_at": "users.created_at",
+}
+
 // регистрация нового пользователя
 func (uc *UserController) Register(c *gin.Context) {
 	var userReg models.UserRegistration
@@ -118,16 +135,19 @@ func (uc *UserController) Login(c *gin.Context) {
 
 	log.Printf("Пароль верный для пользователя: %s", userLogin.Username)
 
-	// Создание JWT токена
-	token, err := middleware.GenerateToken(&user, uc.Config)
+	// Создание пары токенов (доступ + refresh) для новой сессии
+	tokens, err := middleware.IssueTokenPair(uc.DB, &user, uc.Config)
 	if err != nil {
+		log.Printf("Ошибка при создании токенов: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при создании токена"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "успешная авторизация",
-		"token":   token,
+		"message":       "успешная авторизация",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 		"user": gin.H{
 			"id":       user.ID,
 			"username": user.Username,
@@ -138,6 +158,201 @@ func (uc *UserController) Login(c *gin.Context) {
 	})
 }
 
+// обновление пары токенов по refresh-токену
+func (uc *UserController) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, user, err := middleware.RotateRefreshToken(uc.DB, req.RefreshToken, uc.Config)
+	if err != nil {
+		if errors.Is(err, middleware.ErrRefreshTokenReused) {
+			log.Printf("Обнаружено повторное использование refresh-токена, сессия отозвана")
+		}
+		if errors.Is(err, middleware.ErrRefreshTokenInvalid) || errors.Is(err, middleware.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Ошибка при обновлении токенов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении токенов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "токены обновлены",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"user": gin.H{
+			"id":        user.ID,
+			"username":  user.Username,
+			"email":     user.Email,
+			"full_name": user.FullName,
+			"role":      user.Role,
+		},
+	})
+}
+
+// выход из системы: отзыв сессии, к которой относится refresh-токен
+func (uc *UserController) Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := middleware.RevokeRefreshToken(uc.DB, req.RefreshToken); err != nil && !errors.Is(err, middleware.ErrRefreshTokenInvalid) {
+		log.Printf("Ошибка при отзыве сессии: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при выходе из системы"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "выход выполнен",
+	})
+}
+
+// отзыв всех сессий пользователя (только для менеджеров)
+func (uc *UserController) RevokeUserSessions(c *gin.Context) {
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "некорректный ID пользователя"})
+		return
+	}
+
+	var user models.User
+	if result := uc.DB.First(&user, targetID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "пользователь не найден"})
+		return
+	}
+
+	if err := middleware.RevokeUserTokens(uc.DB, user.ID); err != nil {
+		log.Printf("Ошибка при отзыве сессий пользователя %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при отзыве сессий пользователя"})
+		return
+	}
+
+	log.Printf("Все сессии пользователя %s (ID=%d) отозваны", user.Username, user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "все сессии пользователя отозваны",
+	})
+}
+
+// запрос на восстановление пароля: отправка одноразовой ссылки на email
+func (uc *UserController) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ответ одинаковый независимо от того, найден ли пользователь
+	response := gin.H{
+		"message": "если пользователь с таким email существует, на него отправлена ссылка для сброса пароля",
+	}
+
+	var user models.User
+	if result := uc.DB.Where("email = ?", req.Email).First(&user); result.Error != nil {
+		log.Printf("Запрос сброса пароля для неизвестного email: %s", req.Email)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	rawToken, err := middleware.RandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при создании токена"})
+		return
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(uc.Config.Server.PublicURL, "/"), url.QueryEscape(rawToken))
+	body := fmt.Sprintf("Здравствуйте, %s!\n\nДля установки нового пароля перейдите по ссылке:\n%s\n\nСсылка действительна %d мин. и может быть использована один раз.\nЕсли вы не запрашивали сброс пароля, просто проигнорируйте это письмо.",
+		user.FullName, link, uc.Config.Auth.PasswordResetMinutes)
+
+	// предыдущие неиспользованные токены пользователя становятся недействительными;
+	// письмо ставится в очередь, чтобы время ответа не зависело от наличия пользователя
+	err = uc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: middleware.HashToken(rawToken),
+			ExpiresAt: time.Now().Add(time.Minute * time.Duration(uc.Config.Auth.PasswordResetMinutes)),
+		}).Error; err != nil {
+			return err
+		}
+		return notify.Email(tx, user.Email, "Сброс пароля в системе «СистемаКонтроля»", body)
+	})
+	if err != nil {
+		log.Printf("Ошибка при сохранении токена сброса пароля: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при создании токена"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// установка нового пароля по одноразовому токену
+func (uc *UserController) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := models.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при хешировании пароля"})
+		return
+	}
+
+	errInvalidToken := errors.New("недействительная или просроченная ссылка для сброса пароля")
+
+	var user models.User
+	err = uc.DB.Transaction(func(tx *gorm.DB) error {
+		var token models.PasswordResetToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", middleware.HashToken(req.Token), time.Now()).
+			First(&token).Error; err != nil {
+			return errInvalidToken
+		}
+
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return errInvalidToken
+		}
+
+		if err := tx.Model(&token).Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("password_hash", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		// после смены пароля все активные сессии завершаются
+		return middleware.RevokeUserTokens(tx, user.ID)
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Ошибка при сбросе пароля: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении пароля"})
+		return
+	}
+
+	log.Printf("Пароль пользователя %s (ID=%d) сброшен по ссылке из письма", user.Username, user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "пароль успешно изменен",
+	})
+}
+
 // получение профиля текущего пользователя
 func (uc *UserController) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -167,8 +382,25 @@ func (uc *UserController) GetProfile(c *gin.Context) {
 func (uc *UserController) GetAllUsers(c *gin.Context) {
 	// Проверка роли выполняется в middleware
 
+	query := uc.DB.Model(&models.User{}).Session(&gorm.Session{})
+
+	page, perPage := getPagination(c)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		log.Printf("Ошибка при подсчёте пользователей: %v", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении списка пользователей"})
+		return
+	}
+
+	query, err := applySort(c, query, userSortFields, "username", "users.id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var users []models.User
-	if result := uc.DB.Find(&users); result.Error != nil {
+	if result := paginate(query, page, perPage).Find(&users); result.Error != nil {
 		log.Printf("Ошибка при получении списка пользователей: %v", result.Error)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении списка пользователей"})
 		return
@@ -187,10 +419,10 @@ func (uc *UserController) GetAllUsers(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"users": safeUsers,
-		"count": len(users),
-	})
+	response := pageMeta(total, page, perPage)
+	response["users"] = safeUsers
+	response["count"] = len(users)
+	c.JSON(http.StatusOK, response)
 }
 
 // получение списка инженеров (доступно менеджерам и инженерам)
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ошибка сохранения записи, которую после чтения изменил другой запрос
+var errVersionConflict = errors.New("запись изменена другим пользователем")
+
+// значение ETag для версии записи
+func versionETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// проверка заголовка If-Match: условие выполнено, если заголовок не передан,
+// равен * или одно из перечисленных значений совпадает с текущей версией
+func ifMatchVersion(c *gin.Context, version uint) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return true
+	}
+	current := versionETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// сохранение записи с увеличением версии. Запись обновляется, только если
+// версия в базе не изменилась с момента чтения, иначе возвращается
+// errVersionConflict. Столбцы omit не перезаписываются (например, поля,
+// которые ведет фоновый обработчик).
+func saveVersioned(tx *gorm.DB, model interface{}, version *uint, omit ...string) error {
+	read := *version
+	*version = read + 1
+
+	result := tx.Model(model).Where("version = ?", read).
+		Select("*").Omit(append(omit, clause.Associations)...).Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = errVersionConflict
+	}
+	if result.Error != nil {
+		*version = read
+	}
+	return result.Error
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestVersionETag(t *testing.T) {
+	if got := versionETag(12); got != `"12"` {
+		t.Errorf(`versionETag(12) = %s, ожидалось "12"`, got)
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    bool
+	}{
+		{"заголовок не передан", "", true},
+		{"совпадающая версия", `"3"`, true},
+		{"устаревшая версия", `"2"`, false},
+		{"любая версия", "*", true},
+		{"список версий", `"1", "3"`, true},
+		{"список без текущей версии", `"1","2"`, false},
+		{"версия без кавычек", "3", false},
+		{"слабый ETag", `W/"3"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(http.MethodPut, "/defects/1", "application/json", "")
+			if tt.ifMatch != "" {
+				c.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+			if got := ifMatchVersion(c, 3); got != tt.want {
+				t.Errorf("ifMatchVersion(If-Match: %s) = %v, ожидалось %v", tt.ifMatch, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"systemControl_proj/config"
+	"systemControl_proj/database"
+	"systemControl_proj/middleware"
+	"systemControl_proj/models"
+	"systemControl_proj/workers"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// контроллер управления вебхуками (только для менеджеров)
+type WebhookController struct {
+	DB     *gorm.DB
+	Config *config.Config
+}
+
+// создание нового экземпляра контроллера вебхуков
+func NewWebhookController(config *config.Config) *WebhookController {
+	return &WebhookController{
+		DB:     database.DB,
+		Config: config,
+	}
+}
+
+// проверка списка событий подписки
+func validateWebhookEvents(events []models.WebhookEvent) error {
+	if len(events) == 0 {
+		return fmt.Errorf("необходимо указать хотя бы одно событие")
+	}
+	for _, event := range events {
+		if !event.IsValid() {
+			return fmt.Errorf("неизвестное событие: %s", event)
+		}
+	}
+	return nil
+}
+
+// получение списка поддерживаемых событий
+func (wc *WebhookController) GetWebhookEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"events": models.WebhookEvents,
+	})
+}
+
+// регистрация нового вебхука; секрет возвращается только в ответе на создание
+func (wc *WebhookController) CreateWebhook(c *gin.Context) {
+	var webhookCreate models.WebhookCreate
+	if err := c.ShouldBindJSON(&webhookCreate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateWebhookEvents(webhookCreate.Events); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := workers.CheckWebhookURL(webhookCreate.URL, wc.Config.Webhook.AllowPrivateTargets); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret := webhookCreate.Secret
+	if secret == "" {
+		generated, err := middleware.RandomToken(32)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при создании секрета вебхука"})
+			return
+		}
+		secret = generated
+	}
+
+	userID, _ := contextUser(c)
+	webhook := models.Webhook{
+		URL:         webhookCreate.URL,
+		Secret:      secret,
+		Events:      webhookCreate.Events,
+		Active:      true,
+		CreatedByID: userID,
+	}
+	if webhookCreate.Active != nil {
+		webhook.Active = *webhookCreate.Active
+	}
+
+	if result := wc.DB.Create(&webhook); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при сохранении вебхука"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "вебхук успешно создан",
+		"webhook": webhook,
+		"secret":  secret,
+	})
+}
+
+// получение списка вебхуков
+func (wc *WebhookController) GetWebhooks(c *gin.Context) {
+	query := wc.DB.Model(&models.Webhook{}).Session(&gorm.Session{})
+	page, perPage := getPagination(c)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении вебхуков"})
+		return
+	}
+
+	var webhooks []models.Webhook
+	if result := paginate(query, page, perPage).Order("id").Find(&webhooks); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении вебхуков"})
+		return
+	}
+
+	response := pageMeta(total, page, perPage)
+	response["webhooks"] = webhooks
+	c.JSON(http.StatusOK, response)
+}
+
+// получение вебхука по ID
+func (wc *WebhookController) GetWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if result := wc.DB.First(&webhook, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "вебхук не найден"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhook": webhook,
+	})
+}
+
+// обновление адреса, событий, секрета или активности вебхука
+func (wc *WebhookController) UpdateWebhook(c *gin.Context) {
+	var webhookUpdate models.WebhookUpdate
+	if err := c.ShouldBindJSON(&webhookUpdate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var webhook models.Webhook
+	if result := wc.DB.First(&webhook, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "вебхук не найден"})
+		return
+	}
+
+	if webhookUpdate.URL != "" {
+		if err := workers.CheckWebhookURL(webhookUpdate.URL, wc.Config.Webhook.AllowPrivateTargets); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		webhook.URL = webhookUpdate.URL
+	}
+	if webhookUpdate.Events != nil {
+		if err := validateWebhookEvents(webhookUpdate.Events); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		webhook.Events = webhookUpdate.Events
+	}
+	if webhookUpdate.Secret != "" {
+		webhook.Secret = webhookUpdate.Secret
+	}
+	if webhookUpdate.Active != nil {
+		webhook.Active = *webhookUpdate.Active
+	}
+
+	if result := wc.DB.Save(&webhook); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при обновлении вебхука"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "вебхук успешно обновлен",
+		"webhook": webhook,
+	})
+}
+
+// удаление вебхука; недоставленные события перестают отправляться
+func (wc *WebhookController) DeleteWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if result := wc.DB.First(&webhook, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "вебхук не найден"})
+		return
+	}
+
+	if result := wc.DB.Delete(&webhook); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при удалении вебхука"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "вебхук успешно удален",
+	})
+}
+
+// журнал доставок вебхука
+func (wc *WebhookController) GetDeliveries(c *gin.Context) {
+	var webhook models.Webhook
+	if result := wc.DB.First(&webhook, c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "вебхук не найден"})
+		return
+	}
+
+	query := wc.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+	query = query.Session(&gorm.Session{})
+
+	page, perPage := getPagination(c)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении доставок"})
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	if result := paginate(query, page, perPage).Order("created_at DESC, id DESC").Find(&deliveries); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при получении доставок"})
+		return
+	}
+
+	response := pageMeta(total, page, perPage)
+	response["deliveries"] = deliveries
+	c.JSON(http.StatusOK, response)
+}
+
+// поиск доставки вебхука по параметрам маршрута
+func (wc *WebhookController) findDelivery(c *gin.Context) (*models.WebhookDelivery, bool) {
+	var delivery models.WebhookDelivery
+	result := wc.DB.Where("webhook_id = ?", c.Param("id")).First(&delivery, c.Param("delivery_id"))
+	if result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "доставка не найдена"})
+		return nil, false
+	}
+	return &delivery, true
+}
+
+// получение доставки вебхука по ID
+func (wc *WebhookController) GetDelivery(c *gin.Context) {
+	delivery, ok := wc.findDelivery(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+	})
+}
+
+// повторная отправка события: создается новая доставка с тем же содержимым
+func (wc *WebhookController) ReplayDelivery(c *gin.Context) {
+	delivery, ok := wc.findDelivery(c)
+	if !ok {
+		return
+	}
+
+	var webhook models.Webhook
+	if result := wc.DB.First(&webhook, delivery.WebhookID); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "вебхук не найден"})
+		return
+	}
+	if !webhook.Active {
+		c.JSON(http.StatusConflict, gin.H{"error": "вебхук отключен"})
+		return
+	}
+
+	replay := models.WebhookDelivery{
+		WebhookID:     delivery.WebhookID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        models.DeliveryStatusPending,
+		NextAttemptAt: time.Now(),
+		ReplayOfID:    &delivery.ID,
+	}
+	if result := wc.DB.Create(&replay); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка при повторной отправке"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "доставка поставлена в очередь",
+		"delivery": replay,
+	})
+}
@@ -10,9 +10,9 @@ import (
 
 var DB *gorm.DB
 
-// инициализирует соединение с базой данных
-func SetupDatabase(config *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+// строка подключения к PostgreSQL по настройкам приложения
+func DSN(config *config.Config) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		config.Database.Host,
 		config.Database.Port,
 		config.Database.User,
@@ -20,8 +20,11 @@ func SetupDatabase(config *config.Config) (*gorm.DB, error) {
 		config.Database.DBName,
 		config.Database.SSLMode,
 	)
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// инициализирует соединение с базой данных
+func SetupDatabase(config *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(DSN(config)), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,21 @@
+package mailer
+
+import (
+	"log"
+)
+
+// отправка почты в журнал приложения (для разработки)
+type LogMailer struct {
+	From string
+}
+
+// создает почтовый отправитель, пишущий письма в журнал
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{From: from}
+}
+
+// записывает письмо в журнал
+func (m *LogMailer) Send(msg Message) error {
+	log.Printf("Письмо от %s для %s\nТема: %s\n\n%s", m.From, msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"fmt"
+	"systemControl_proj/config"
+)
+
+// почтовое сообщение
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// отправка почтовых сообщений
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// инициализирует отправку почты в соответствии с настройками
+func SetupMailer(config *config.Config) (Mailer, error) {
+	var m Mailer
+
+	switch config.Mail.Driver {
+	case "smtp":
+		m = NewSMTPMailer(config.Mail)
+	case "log", "":
+		m = NewLogMailer(config.Mail.From)
+	default:
+		return nil, fmt.Errorf("неизвестный способ отправки почты: %s", config.Mail.Driver)
+	}
+
+	return m, nil
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
 	"systemControl_proj/config"
 	"systemControl_proj/database"
+	"systemControl_proj/mailer"
+	"systemControl_proj/realtime"
 	"systemControl_proj/routes"
+	"systemControl_proj/storage"
+	"systemControl_proj/workers"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,6 +33,26 @@ func main() {
 
 	log.Println("Соединение с базой данных установлено")
 
+	if _, err := storage.SetupStorage(cfg); err != nil {
+		log.Fatalf("Ошибка инициализации хранилища файлов: %v", err)
+	}
+
+	mail, err := mailer.SetupMailer(cfg)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации отправки почты: %v", err)
+	}
+
+	// фоновая отправка писем из очереди
+	go workers.NewEmailDispatcher(db, mail, cfg).Run(context.Background())
+	// фоновая доставка вебхуков
+	go workers.NewWebhookDispatcher(db, cfg).Run(context.Background())
+	// контроль сроков устранения дефектов
+	go workers.NewOverdueScheduler(db, cfg).Run(context.Background())
+
+	// получение событий проектов из PostgreSQL для потоков SSE
+	broker := realtime.SetupBroker(cfg)
+	go broker.Run(context.Background())
+
 	router := gin.Default()
 
 	routes.SetupRoutes(router, cfg)
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 	"systemControl_proj/config"
+	"systemControl_proj/database"
 	"systemControl_proj/models"
 	"time"
 
@@ -17,17 +18,20 @@ type JWTClaims struct {
 	UserID   uint        `json:"user_id"`
 	Username string      `json:"username"`
 	Role     models.Role `json:"role"`
+	// идентификатор сессии (семейства refresh-токенов)
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// создание нового JWT токена для пользователя
-func GenerateToken(user *models.User, cfg *config.Config) (string, error) {
+// создание нового короткоживущего JWT токена доступа для пользователя
+func GenerateToken(user *models.User, sessionID string, cfg *config.Config) (string, error) {
 	claims := JWTClaims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(cfg.Auth.JWTExpirationHrs))),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * time.Duration(cfg.Auth.AccessTokenMinutes))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -64,6 +68,46 @@ func ValidateToken(tokenString string, cfg *config.Config) (*JWTClaims, error) {
 
 // проверка JWT токена в заголовке запроса
 func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return authenticate(cfg)
+}
+
+// проверка доступа к потоку событий: EventSource в браузере не умеет
+// передавать заголовки, поэтому вместо токена допускается одноразовый
+// билет в параметре ticket (токен доступа в URL попал бы в журналы запросов)
+func StreamAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	withToken := authenticate(cfg)
+	return func(c *gin.Context) {
+		raw := c.Query("ticket")
+		if c.GetHeader("Authorization") != "" || raw == "" {
+			withToken(c)
+			return
+		}
+
+		ticket, err := consumeStreamTicket(database.DB, raw, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrStreamTicketInvalid.Error()})
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if !IsSessionActive(database.DB, ticket.SessionID) || database.DB.First(&user, ticket.UserID).Error != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "сессия отозвана, выполните вход заново"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", user.ID)
+		c.Set("username", user.Username)
+		c.Set("role", user.Role)
+		c.Set("sessionID", ticket.SessionID)
+		c.Set("tokenExpiresAt", ticket.TokenExpiresAt)
+
+		c.Next()
+	}
+}
+
+func authenticate(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -86,10 +130,21 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// токен отклоняется, если его сессия отозвана (выход, повторное использование refresh-токена)
+		if claims.SessionID == "" || !IsSessionActive(database.DB, claims.SessionID) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "сессия отозвана, выполните вход заново"})
+			c.Abort()
+			return
+		}
+
 		// сохранение данных пользователя в контексте
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		c.Set("sessionID", claims.SessionID)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"systemControl_proj/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// срок действия билета на подключение к потоку событий
+const StreamTicketTTL = time.Minute
+
+// ошибка: билет не найден, уже использован, истёк или выдан для другого проекта
+var ErrStreamTicketInvalid = errors.New("недействительный билет потока событий")
+
+// выдача одноразового билета для подключения к потоку событий проекта;
+// поток, открытый по билету, живёт не дольше токена доступа, которым билет получен
+func IssueStreamTicket(db *gorm.DB, userID, projectID uint, sessionID string, tokenExpiresAt time.Time) (string, error) {
+	raw, err := RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// неиспользованные билеты удаляются по истечении срока
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&models.StreamTicket{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.StreamTicket{
+			UserID:         userID,
+			ProjectID:      projectID,
+			SessionID:      sessionID,
+			TicketHash:     HashToken(raw),
+			TokenExpiresAt: tokenExpiresAt,
+			ExpiresAt:      time.Now().Add(StreamTicketTTL),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// погашение билета: билет удаляется при первом использовании
+func consumeStreamTicket(db *gorm.DB, raw, projectID string) (*models.StreamTicket, error) {
+	var tickets []models.StreamTicket
+	if err := db.Clauses(clause.Returning{}).
+		Where("ticket_hash = ?", HashToken(raw)).
+		Delete(&tickets).Error; err != nil {
+		return nil, err
+	}
+	if len(tickets) == 0 {
+		return nil, ErrStreamTicketInvalid
+	}
+
+	ticket := tickets[0]
+	if time.Now().After(ticket.ExpiresAt) || strconv.FormatUint(uint64(ticket.ProjectID), 10) != projectID {
+		return nil, ErrStreamTicketInvalid
+	}
+	return &ticket, nil
+}
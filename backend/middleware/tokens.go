@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"systemControl_proj/config"
+	"systemControl_proj/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ошибка: refresh-токен не найден или истёк
+	ErrRefreshTokenInvalid = errors.New("недействительный refresh-токен")
+	// ошибка: повторное использование refresh-токена, семейство отозвано
+	ErrRefreshTokenReused = errors.New("повторное использование refresh-токена, сессия отозвана")
+)
+
+// пара токенов, выдаваемая при входе и обновлении
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// генерация случайной строки для токенов и идентификаторов (size — число случайных байт)
+func RandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// хеш одноразового токена для хранения в базе данных
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// выдача пары токенов для новой сессии пользователя
+func IssueTokenPair(db *gorm.DB, user *models.User, cfg *config.Config) (*TokenPair, error) {
+	familyID, err := RandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	pair, _, err := issueTokenPair(db, user, familyID, cfg)
+	return pair, err
+}
+
+// выдача пары токенов в рамках семейства (сессии)
+func issueTokenPair(tx *gorm.DB, user *models.User, familyID string, cfg *config.Config) (*TokenPair, *models.RefreshToken, error) {
+	accessToken, err := GenerateToken(user, familyID, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawRefresh, err := RandomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refresh := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: HashToken(rawRefresh),
+		ExpiresAt: time.Now().Add(time.Hour * 24 * time.Duration(cfg.Auth.RefreshTokenDays)),
+	}
+	if err := tx.Create(&refresh).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefresh,
+		ExpiresIn:    cfg.Auth.AccessTokenMinutes * 60,
+	}, &refresh, nil
+}
+
+// обмен refresh-токена на новую пару (ротация);
+// повторное использование токена отзывает всё семейство
+func RotateRefreshToken(db *gorm.DB, rawToken string, cfg *config.Config) (*TokenPair, *models.User, error) {
+	var (
+		pair   *TokenPair
+		user   models.User
+		reused bool
+	)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", HashToken(rawToken)).
+			First(&current).Error; err != nil {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err := checkRefreshToken(&current, time.Now()); err != nil {
+			if errors.Is(err, ErrRefreshTokenReused) {
+				reused = true
+				return revokeFamily(tx, current.FamilyID)
+			}
+			return err
+		}
+
+		if err := tx.First(&user, current.UserID).Error; err != nil {
+			return ErrRefreshTokenInvalid
+		}
+
+		next, nextToken, err := issueTokenPair(tx, &user, current.FamilyID, cfg)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"replaced_by_id": nextToken.ID,
+		}).Error; err != nil {
+			return err
+		}
+
+		pair = next
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if reused {
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	return pair, &user, nil
+}
+
+// проверка предъявленного refresh-токена: отозванный токен означает повторное
+// использование (даже если он уже истёк), истёкший — недействителен
+func checkRefreshToken(token *models.RefreshToken, now time.Time) error {
+	if token.RevokedAt != nil {
+		return ErrRefreshTokenReused
+	}
+	if now.After(token.ExpiresAt) {
+		return ErrRefreshTokenInvalid
+	}
+	return nil
+}
+
+// отзыв сессии, к которой относится refresh-токен
+func RevokeRefreshToken(db *gorm.DB, rawToken string) error {
+	var token models.RefreshToken
+	if err := db.Where("token_hash = ?", HashToken(rawToken)).First(&token).Error; err != nil {
+		return ErrRefreshTokenInvalid
+	}
+	return revokeFamily(db, token.FamilyID)
+}
+
+// отзыв всех сессий пользователя
+func RevokeUserTokens(db *gorm.DB, userID uint) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// отзыв всех токенов семейства
+func revokeFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// проверка, что сессия не отозвана (в семействе есть действующий refresh-токен)
+func IsSessionActive(db *gorm.DB, familyID string) bool {
+	var count int64
+	if err := db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"errors"
+	"systemControl_proj/config"
+	"systemControl_proj/models"
+	"testing"
+	"time"
+)
+
+func TestHashToken(t *testing.T) {
+	tests := []struct {
+		token string
+		hash  string
+	}{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	}
+
+	for _, tt := range tests {
+		if got := HashToken(tt.token); got != tt.hash {
+			t.Errorf("HashToken(%q) = %s, ожидалось %s", tt.token, got, tt.hash)
+		}
+	}
+	if HashToken("refresh-a") == HashToken("refresh-b") {
+		t.Error("разные токены дали одинаковый хеш")
+	}
+}
+
+func TestRandomToken(t *testing.T) {
+	seen := map[string]bool{}
+	for _, size := range []int{16, 24, 32} {
+		token, err := RandomToken(size)
+		if err != nil {
+			t.Fatalf("RandomToken(%d): %v", size, err)
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			t.Fatalf("токен %q не в base64url: %v", token, err)
+		}
+		if len(raw) != size {
+			t.Errorf("RandomToken(%d) содержит %d байт", size, len(raw))
+		}
+		if seen[token] {
+			t.Errorf("токен %q выдан повторно", token)
+		}
+		seen[token] = true
+	}
+}
+
+func TestCheckRefreshToken(t *testing.T) {
+	now := time.Date(2026, 5, 20, 12, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		token models.RefreshToken
+		err   error
+	}{
+		{"действующий", models.RefreshToken{ExpiresAt: now.Add(time.Hour)}, nil},
+		{"истёк", models.RefreshToken{ExpiresAt: now.Add(-time.Second)}, ErrRefreshTokenInvalid},
+		{"уже обменян", models.RefreshToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, ErrRefreshTokenReused},
+		// предъявление отозванного токена отзывает семейство и после истечения срока
+		{"обменян и истёк", models.RefreshToken{ExpiresAt: now.Add(-time.Hour), RevokedAt: &revokedAt}, ErrRefreshTokenReused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkRefreshToken(&tt.token, now); !errors.Is(err, tt.err) {
+				t.Errorf("ошибка %v, ожидалась %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAccessTokenSession(t *testing.T) {
+	cfg := &config.Config{Auth: config.AuthConfig{JWTSecret: "секрет", AccessTokenMinutes: 15}}
+	user := &models.User{ID: 7, Username: "ivanov", Role: models.RoleEngineer}
+
+	token, err := GenerateToken(user, "family-1", cfg)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	claims, err := ValidateToken(token, cfg)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserID != user.ID || claims.SessionID != "family-1" || claims.Role != user.Role {
+		t.Errorf("неожиданные claims: %+v", claims)
+	}
+
+	other := &config.Config{Auth: config.AuthConfig{JWTSecret: "другой", AccessTokenMinutes: 15}}
+	if _, err := ValidateToken(token, other); err == nil {
+		t.Error("токен принят с чужим секретом")
+	}
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateDefectHistoryTable миграция для создания таблицы истории изменений дефектов
+type CreateDefectHistoryTable struct{}
+
+// Up создает таблицу истории изменений дефектов
+func (m *CreateDefectHistoryTable) Up(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS defect_history (
+			id SERIAL PRIMARY KEY,
+			defect_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			field VARCHAR(50) NOT NULL,
+			old_value TEXT,
+			new_value TEXT,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (defect_id) REFERENCES defects(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_defect_history_defect_id ON defect_history(defect_id, created_at)`).Error
+}
+
+// Down удаляет таблицу истории изменений дефектов
+func (m *CreateDefectHistoryTable) Down(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS defect_history`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateDefectHistoryTable) Name() string {
+	return "006_create_defect_history_table"
+}
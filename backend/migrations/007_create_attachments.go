@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateAttachmentsTable миграция для создания таблицы вложений к дефектам
+type CreateAttachmentsTable struct{}
+
+// Up создает таблицу вложений
+func (m *CreateAttachmentsTable) Up(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS attachments (
+			id SERIAL PRIMARY KEY,
+			defect_id INTEGER NOT NULL,
+			uploader_id INTEGER NOT NULL,
+			file_name VARCHAR(255) NOT NULL,
+			mime_type VARCHAR(100),
+			size BIGINT NOT NULL DEFAULT 0,
+			sha256 CHAR(64),
+			storage_key VARCHAR(255) NOT NULL,
+			thumbnail_key VARCHAR(255),
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP WITH TIME ZONE,
+			FOREIGN KEY (defect_id) REFERENCES defects(id),
+			FOREIGN KEY (uploader_id) REFERENCES users(id)
+		)
+	`).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_attachments_defect_id ON attachments(defect_id)`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_attachments_deleted_at ON attachments(deleted_at)`).Error
+}
+
+// Down удаляет таблицу вложений
+func (m *CreateAttachmentsTable) Down(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS attachments`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateAttachmentsTable) Name() string {
+	return "007_create_attachments_table"
+}
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddDefectSearch миграция для полнотекстового поиска по дефектам и комментариям
+type AddDefectSearch struct{}
+
+// Up добавляет столбец tsvector, триггеры его обновления и GIN-индекс
+func (m *AddDefectSearch) Up(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE defects ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+
+		// поисковый вектор: название (A), описание (B), текст комментариев (C)
+		`CREATE OR REPLACE FUNCTION defect_search_vector(p_id INTEGER, p_title TEXT, p_description TEXT) RETURNS tsvector AS $$
+			SELECT setweight(to_tsvector('russian', COALESCE(p_title, '')), 'A') ||
+				setweight(to_tsvector('russian', COALESCE(p_description, '')), 'B') ||
+				setweight(to_tsvector('russian', COALESCE((
+					SELECT string_agg(content, ' ') FROM comments
+					WHERE defect_id = p_id AND deleted_at IS NULL
+				), '')), 'C')
+		$$ LANGUAGE sql STABLE`,
+
+		`CREATE OR REPLACE FUNCTION defects_search_vector_trigger() RETURNS trigger AS $$
+		BEGIN
+			NEW.search_vector := defect_search_vector(NEW.id, NEW.title, NEW.description);
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql`,
+
+		`CREATE OR REPLACE FUNCTION comments_search_vector_trigger() RETURNS trigger AS $$
+		DECLARE
+			target_id INTEGER;
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				target_id := OLD.defect_id;
+			ELSE
+				target_id := NEW.defect_id;
+			END IF;
+			UPDATE defects SET search_vector = defect_search_vector(id, title, description) WHERE id = target_id;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+
+		`DROP TRIGGER IF EXISTS defects_search_vector_update ON defects`,
+		`CREATE TRIGGER defects_search_vector_update
+			BEFORE INSERT OR UPDATE OF title, description ON defects
+			FOR EACH ROW EXECUTE FUNCTION defects_search_vector_trigger()`,
+
+		`DROP TRIGGER IF EXISTS comments_search_vector_update ON comments`,
+		`CREATE TRIGGER comments_search_vector_update
+			AFTER INSERT OR UPDATE OR DELETE ON comments
+			FOR EACH ROW EXECUTE FUNCTION comments_search_vector_trigger()`,
+
+		// заполнение вектора для существующих дефектов
+		`UPDATE defects SET search_vector = defect_search_vector(id, title, description)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_defects_search_vector ON defects USING GIN (search_vector)`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет поисковый столбец, триггеры и функции
+func (m *AddDefectSearch) Down(tx *gorm.DB) error {
+	statements := []string{
+		`DROP TRIGGER IF EXISTS comments_search_vector_update ON comments`,
+		`DROP TRIGGER IF EXISTS defects_search_vector_update ON defects`,
+		`DROP FUNCTION IF EXISTS comments_search_vector_trigger()`,
+		`DROP FUNCTION IF EXISTS defects_search_vector_trigger()`,
+		`DROP FUNCTION IF EXISTS defect_search_vector(INTEGER, TEXT, TEXT)`,
+		`DROP INDEX IF EXISTS idx_defects_search_vector`,
+		`ALTER TABLE defects DROP COLUMN IF EXISTS search_vector`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Name возвращает имя миграции
+func (m *AddDefectSearch) Name() string {
+	return "008_add_defect_search"
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateRefreshTokensTable миграция для создания таблицы refresh-токенов
+type CreateRefreshTokensTable struct{}
+
+// Up создает таблицу refresh-токенов
+func (m *CreateRefreshTokensTable) Up(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			family_id VARCHAR(64) NOT NULL,
+			token_hash CHAR(64) NOT NULL UNIQUE,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			revoked_at TIMESTAMP WITH TIME ZONE,
+			replaced_by_id INTEGER,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (replaced_by_id) REFERENCES refresh_tokens(id)
+		)
+	`).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id)`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id)`).Error
+}
+
+// Down удаляет таблицу refresh-токенов
+func (m *CreateRefreshTokensTable) Down(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS refresh_tokens`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateRefreshTokensTable) Name() string {
+	return "009_create_refresh_tokens_table"
+}
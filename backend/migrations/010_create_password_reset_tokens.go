@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreatePasswordResetTokensTable миграция для создания таблицы токенов сброса пароля
+type CreatePasswordResetTokensTable struct{}
+
+// Up создает таблицу токенов сброса пароля
+func (m *CreatePasswordResetTokensTable) Up(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			token_hash CHAR(64) NOT NULL UNIQUE,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			used_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)
+	`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id)`).Error
+}
+
+// Down удаляет таблицу токенов сброса пароля
+func (m *CreatePasswordResetTokensTable) Down(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS password_reset_tokens`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreatePasswordResetTokensTable) Name() string {
+	return "010_create_password_reset_tokens_table"
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateProjectMembersTable миграция для создания таблицы участников проектов
+type CreateProjectMembersTable struct{}
+
+// Up создает таблицу участников проектов и заполняет её по существующим данным
+func (m *CreateProjectMembersTable) Up(tx *gorm.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS project_members (
+			id SERIAL PRIMARY KEY,
+			project_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			role VARCHAR(20) NOT NULL DEFAULT 'observer',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (project_id) REFERENCES projects(id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			UNIQUE (project_id, user_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members(user_id)`,
+
+		// менеджеры проектов становятся участниками своих проектов
+		`INSERT INTO project_members (project_id, user_id, role)
+			SELECT id, manager_id, 'manager' FROM projects WHERE deleted_at IS NULL
+			ON CONFLICT (project_id, user_id) DO NOTHING`,
+
+		// авторы и исполнители дефектов сохраняют доступ к своим проектам
+		`INSERT INTO project_members (project_id, user_id, role)
+			SELECT DISTINCT d.project_id, u.id, u.role
+			FROM defects d
+			JOIN users u ON u.id IN (d.reporter_id, d.assignee_id)
+			WHERE d.deleted_at IS NULL
+			ON CONFLICT (project_id, user_id) DO NOTHING`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет таблицу участников проектов
+func (m *CreateProjectMembersTable) Down(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS project_members`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateProjectMembersTable) Name() string {
+	return "011_create_project_members_table"
+}
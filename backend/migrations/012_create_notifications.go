@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateNotificationsTable миграция для создания таблицы уведомлений
+type CreateNotificationsTable struct{}
+
+// Up создает таблицу уведомлений
+func (m *CreateNotificationsTable) Up(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS notifications (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			type VARCHAR(50) NOT NULL,
+			message TEXT NOT NULL,
+			defect_id INTEGER,
+			project_id INTEGER,
+			actor_id INTEGER,
+			read_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (defect_id) REFERENCES defects(id),
+			FOREIGN KEY (project_id) REFERENCES projects(id),
+			FOREIGN KEY (actor_id) REFERENCES users(id)
+		)
+	`).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at DESC)`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL`).Error
+}
+
+// Down удаляет таблицу уведомлений
+func (m *CreateNotificationsTable) Down(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS notifications`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateNotificationsTable) Name() string {
+	return "012_create_notifications_table"
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateEmailOutboxTable миграция для создания очереди исходящих писем
+type CreateEmailOutboxTable struct{}
+
+// Up создает таблицу очереди исходящих писем
+func (m *CreateEmailOutboxTable) Up(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS email_outbox (
+			id SERIAL PRIMARY KEY,
+			recipient VARCHAR(100) NOT NULL,
+			subject VARCHAR(255) NOT NULL,
+			body TEXT NOT NULL,
+			defect_id INTEGER,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			last_error TEXT,
+			sent_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (defect_id) REFERENCES defects(id)
+		)
+	`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_email_outbox_pending ON email_outbox(next_attempt_at) WHERE status = 'pending'`).Error
+}
+
+// Down удаляет таблицу очереди исходящих писем
+func (m *CreateEmailOutboxTable) Down(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS email_outbox`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateEmailOutboxTable) Name() string {
+	return "013_create_email_outbox_table"
+}
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateWebhooksTables миграция для создания таблиц вебхуков и журнала доставок
+type CreateWebhooksTables struct{}
+
+// Up создает таблицы вебхуков и доставок
+func (m *CreateWebhooksTables) Up(tx *gorm.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id SERIAL PRIMARY KEY,
+			url VARCHAR(500) NOT NULL,
+			secret VARCHAR(255) NOT NULL,
+			events TEXT NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_by_id INTEGER NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP WITH TIME ZONE,
+			FOREIGN KEY (created_by_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id SERIAL PRIMARY KEY,
+			webhook_id INTEGER NOT NULL,
+			event VARCHAR(50) NOT NULL,
+			payload JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			response_status INTEGER NOT NULL DEFAULT 0,
+			response_body TEXT,
+			last_error TEXT,
+			delivered_at TIMESTAMP WITH TIME ZONE,
+			replay_of_id INTEGER,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (webhook_id) REFERENCES webhooks(id),
+			FOREIGN KEY (replay_of_id) REFERENCES webhook_deliveries(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет таблицы вебхуков и доставок
+func (m *CreateWebhooksTables) Down(tx *gorm.DB) error {
+	if err := tx.Exec(`DROP TABLE IF EXISTS webhook_deliveries`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`DROP TABLE IF EXISTS webhooks`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateWebhooksTables) Name() string {
+	return "014_create_webhooks_tables"
+}
@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddDefectOverdue миграция для отслеживания просрочки и эскалаций дефектов
+type AddDefectOverdue struct{}
+
+// Up добавляет отметку просрочки и таблицу эскалаций
+func (m *AddDefectOverdue) Up(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE defects ADD COLUMN IF NOT EXISTS overdue_since TIMESTAMP WITH TIME ZONE`,
+		`CREATE INDEX IF NOT EXISTS idx_defects_open_due_date ON defects(due_date)
+			WHERE status NOT IN ('closed', 'canceled') AND deleted_at IS NULL`,
+		`CREATE TABLE IF NOT EXISTS defect_escalations (
+			id SERIAL PRIMARY KEY,
+			defect_id INTEGER NOT NULL,
+			level VARCHAR(30) NOT NULL,
+			due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+			recipient_id INTEGER,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (defect_id) REFERENCES defects(id),
+			FOREIGN KEY (recipient_id) REFERENCES users(id)
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_defect_escalations_unique ON defect_escalations(defect_id, level, due_date)`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет таблицу эскалаций и отметку просрочки
+func (m *AddDefectOverdue) Down(tx *gorm.DB) error {
+	statements := []string{
+		`DROP TABLE IF EXISTS defect_escalations`,
+		`DROP INDEX IF EXISTS idx_defects_open_due_date`,
+		`ALTER TABLE defects DROP COLUMN IF EXISTS overdue_since`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Name возвращает имя миграции
+func (m *AddDefectOverdue) Name() string {
+	return "015_add_defect_overdue"
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateProjectStagesTable миграция для создания этапов проекта и привязки дефектов к этапу
+type CreateProjectStagesTable struct{}
+
+// Up создает таблицу этапов и добавляет дефектам ссылку на этап
+func (m *CreateProjectStagesTable) Up(tx *gorm.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS project_stages (
+			id SERIAL PRIMARY KEY,
+			project_id INTEGER NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			planned_start TIMESTAMP WITH TIME ZONE,
+			planned_end TIMESTAMP WITH TIME ZONE,
+			actual_start TIMESTAMP WITH TIME ZONE,
+			actual_end TIMESTAMP WITH TIME ZONE,
+			status VARCHAR(20) NOT NULL DEFAULT 'planned',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP WITH TIME ZONE,
+			FOREIGN KEY (project_id) REFERENCES projects(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_stages_project_id ON project_stages(project_id, sort_order)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_stages_deleted_at ON project_stages(deleted_at)`,
+		`ALTER TABLE defects ADD COLUMN IF NOT EXISTS stage_id INTEGER REFERENCES project_stages(id)`,
+		`CREATE INDEX IF NOT EXISTS idx_defects_stage_id ON defects(stage_id)`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет ссылку на этап у дефектов и таблицу этапов
+func (m *CreateProjectStagesTable) Down(tx *gorm.DB) error {
+	if err := tx.Exec(`ALTER TABLE defects DROP COLUMN IF EXISTS stage_id`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`DROP TABLE IF EXISTS project_stages`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateProjectStagesTable) Name() string {
+	return "016_create_project_stages_table"
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateProjectLocationsTable миграция для создания иерархии мест на объекте
+type CreateProjectLocationsTable struct{}
+
+// Up создает таблицу мест и добавляет дефектам ссылку на место
+func (m *CreateProjectLocationsTable) Up(tx *gorm.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS project_locations (
+			id SERIAL PRIMARY KEY,
+			project_id INTEGER NOT NULL,
+			parent_id INTEGER,
+			name VARCHAR(255) NOT NULL,
+			kind VARCHAR(20) NOT NULL DEFAULT 'other',
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP WITH TIME ZONE,
+			FOREIGN KEY (project_id) REFERENCES projects(id),
+			FOREIGN KEY (parent_id) REFERENCES project_locations(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_locations_project_id ON project_locations(project_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_locations_parent_id ON project_locations(parent_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_project_locations_deleted_at ON project_locations(deleted_at)`,
+		`ALTER TABLE defects ADD COLUMN IF NOT EXISTS location_id INTEGER REFERENCES project_locations(id)`,
+		`CREATE INDEX IF NOT EXISTS idx_defects_location_id ON defects(location_id)`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет ссылку на место у дефектов и таблицу мест
+func (m *CreateProjectLocationsTable) Down(tx *gorm.DB) error {
+	if err := tx.Exec(`ALTER TABLE defects DROP COLUMN IF EXISTS location_id`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`DROP TABLE IF EXISTS project_locations`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateProjectLocationsTable) Name() string {
+	return "017_create_project_locations_table"
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateFloorPlansTable миграция для создания планов мест и меток дефектов на планах
+type CreateFloorPlansTable struct{}
+
+// Up создает таблицу планов и добавляет дефектам метку на плане
+func (m *CreateFloorPlansTable) Up(tx *gorm.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS floor_plans (
+			id SERIAL PRIMARY KEY,
+			project_id INTEGER NOT NULL,
+			location_id INTEGER NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			file_name VARCHAR(255) NOT NULL,
+			mime_type VARCHAR(100) NOT NULL,
+			size BIGINT NOT NULL DEFAULT 0,
+			page INTEGER NOT NULL DEFAULT 1,
+			width INTEGER NOT NULL DEFAULT 0,
+			height INTEGER NOT NULL DEFAULT 0,
+			storage_key VARCHAR(255) NOT NULL,
+			uploaded_by_id INTEGER NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP WITH TIME ZONE,
+			FOREIGN KEY (project_id) REFERENCES projects(id),
+			FOREIGN KEY (location_id) REFERENCES project_locations(id),
+			FOREIGN KEY (uploaded_by_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_floor_plans_location_id ON floor_plans(location_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_floor_plans_deleted_at ON floor_plans(deleted_at)`,
+		`ALTER TABLE defects ADD COLUMN IF NOT EXISTS plan_id INTEGER REFERENCES floor_plans(id)`,
+		`ALTER TABLE defects ADD COLUMN IF NOT EXISTS pin_x DOUBLE PRECISION`,
+		`ALTER TABLE defects ADD COLUMN IF NOT EXISTS pin_y DOUBLE PRECISION`,
+		`ALTER TABLE defects DROP CONSTRAINT IF EXISTS chk_defects_pin`,
+		`ALTER TABLE defects ADD CONSTRAINT chk_defects_pin CHECK (
+			(plan_id IS NULL AND pin_x IS NULL AND pin_y IS NULL) OR
+			(plan_id IS NOT NULL AND pin_x BETWEEN 0 AND 1 AND pin_y BETWEEN 0 AND 1)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_defects_plan_id ON defects(plan_id)`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет метки дефектов и таблицу планов
+func (m *CreateFloorPlansTable) Down(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE defects DROP CONSTRAINT IF EXISTS chk_defects_pin`,
+		`ALTER TABLE defects DROP COLUMN IF EXISTS pin_y`,
+		`ALTER TABLE defects DROP COLUMN IF EXISTS pin_x`,
+		`ALTER TABLE defects DROP COLUMN IF EXISTS plan_id`,
+		`DROP TABLE IF EXISTS floor_plans`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Name возвращает имя миграции
+func (m *CreateFloorPlansTable) Name() string {
+	return "018_create_floor_plans_table"
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddCoordinates миграция для добавления GPS-координат проектам и дефектам
+type AddCoordinates struct{}
+
+// Up добавляет широту и долготу с проверкой диапазонов и индексами
+func (m *AddCoordinates) Up(tx *gorm.DB) error {
+	statements := []string{}
+	for _, table := range []string{"projects", "defects"} {
+		statements = append(statements,
+			`ALTER TABLE `+table+` ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION`,
+			`ALTER TABLE `+table+` ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION`,
+			`ALTER TABLE `+table+` DROP CONSTRAINT IF EXISTS chk_`+table+`_coordinates`,
+			`ALTER TABLE `+table+` ADD CONSTRAINT chk_`+table+`_coordinates CHECK (
+				(latitude IS NULL AND longitude IS NULL) OR
+				(latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_`+table+`_coordinates ON `+table+`(latitude, longitude) WHERE latitude IS NOT NULL`,
+		)
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет координаты проектов и дефектов
+func (m *AddCoordinates) Down(tx *gorm.DB) error {
+	for _, table := range []string{"projects", "defects"} {
+		statements := []string{
+			`DROP INDEX IF EXISTS idx_` + table + `_coordinates`,
+			`ALTER TABLE ` + table + ` DROP CONSTRAINT IF EXISTS chk_` + table + `_coordinates`,
+			`ALTER TABLE ` + table + ` DROP COLUMN IF EXISTS longitude`,
+			`ALTER TABLE ` + table + ` DROP COLUMN IF EXISTS latitude`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Name возвращает имя миграции
+func (m *AddCoordinates) Name() string {
+	return "019_add_coordinates"
+}
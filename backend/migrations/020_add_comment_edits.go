@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddCommentEdits миграция для редактирования комментариев и упоминаний пользователей
+type AddCommentEdits struct{}
+
+// Up добавляет отметку редактирования, таблицу прежних редакций и таблицу упоминаний
+func (m *AddCommentEdits) Up(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE comments ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP WITH TIME ZONE`,
+		`CREATE TABLE IF NOT EXISTS comment_revisions (
+			id SERIAL PRIMARY KEY,
+			comment_id INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			edited_by_id INTEGER NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (edited_by_id) REFERENCES users(id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_comment_revisions_comment_id ON comment_revisions(comment_id, created_at)`,
+		`CREATE TABLE IF NOT EXISTS comment_mentions (
+			id SERIAL PRIMARY KEY,
+			comment_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (comment_id) REFERENCES comments(id),
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			UNIQUE (comment_id, user_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_comment_mentions_user_id ON comment_mentions(user_id)`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет упоминания, прежние редакции и отметку редактирования
+func (m *AddCommentEdits) Down(tx *gorm.DB) error {
+	statements := []string{
+		`DROP TABLE IF EXISTS comment_mentions`,
+		`DROP TABLE IF EXISTS comment_revisions`,
+		`ALTER TABLE comments DROP COLUMN IF EXISTS edited_at`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Name возвращает имя миграции
+func (m *AddCommentEdits) Name() string {
+	return "020_add_comment_edits"
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddCommentReplies миграция для ответов на комментарии
+type AddCommentReplies struct{}
+
+// Up добавляет комментариям ссылку на родительский комментарий
+func (m *AddCommentReplies) Up(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES comments(id)`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id)`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет ссылку на родительский комментарий
+func (m *AddCommentReplies) Down(tx *gorm.DB) error {
+	return tx.Exec(`ALTER TABLE comments DROP COLUMN IF EXISTS parent_id`).Error
+}
+
+// Name возвращает имя миграции
+func (m *AddCommentReplies) Name() string {
+	return "021_add_comment_replies"
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// AddVersions миграция для оптимистической блокировки дефектов и проектов
+type AddVersions struct{}
+
+// Up добавляет дефектам и проектам номер версии записи
+func (m *AddVersions) Up(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE defects ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1`,
+		`ALTER TABLE projects ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down удаляет номер версии у дефектов и проектов
+func (m *AddVersions) Down(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE defects DROP COLUMN IF EXISTS version`,
+		`ALTER TABLE projects DROP COLUMN IF EXISTS version`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Name возвращает имя миграции
+func (m *AddVersions) Name() string {
+	return "022_add_versions"
+}
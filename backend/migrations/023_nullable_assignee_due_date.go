@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// NullableAssigneeDueDate миграция для хранения незаданных исполнителя и срока как NULL
+type NullableAssigneeDueDate struct{}
+
+// Up заменяет нулевые значения исполнителя и срока устранения на NULL
+func (m *NullableAssigneeDueDate) Up(tx *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE defects ALTER COLUMN assignee_id DROP NOT NULL`,
+		`ALTER TABLE defects ALTER COLUMN due_date DROP NOT NULL`,
+		`UPDATE defects SET assignee_id = NULL WHERE assignee_id = 0`,
+		`UPDATE defects SET due_date = NULL, overdue_since = NULL WHERE due_date < '0001-01-02'`,
+	}
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down возвращает нулевую дату для незаданного срока устранения. Откат неполный:
+// assignee_id остается NULL, так как значение 0 нарушает внешний ключ на users,
+// а ограничения NOT NULL не восстанавливаются, поскольку в схеме из миграции 003
+// оба столбца изначально допускают NULL
+func (m *NullableAssigneeDueDate) Down(tx *gorm.DB) error {
+	return tx.Exec(`UPDATE defects SET due_date = '0001-01-01 00:00:00+00' WHERE due_date IS NULL`).Error
+}
+
+// Name возвращает имя миграции
+func (m *NullableAssigneeDueDate) Name() string {
+	return "023_nullable_assignee_due_date"
+}
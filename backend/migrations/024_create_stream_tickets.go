@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// CreateStreamTicketsTable миграция для создания таблицы билетов потоков событий
+type CreateStreamTicketsTable struct{}
+
+// Up создает таблицу одноразовых билетов для подключения к потокам событий
+func (m *CreateStreamTicketsTable) Up(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS stream_tickets (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			project_id INTEGER NOT NULL,
+			session_id VARCHAR(64) NOT NULL,
+			ticket_hash CHAR(64) NOT NULL UNIQUE,
+			token_expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id),
+			FOREIGN KEY (project_id) REFERENCES projects(id)
+		)
+	`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_stream_tickets_expires_at ON stream_tickets(expires_at)`).Error
+}
+
+// Down удаляет таблицу билетов потоков событий
+func (m *CreateStreamTicketsTable) Down(tx *gorm.DB) error {
+	return tx.Exec(`DROP TABLE IF EXISTS stream_tickets`).Error
+}
+
+// Name возвращает имя миграции
+func (m *CreateStreamTicketsTable) Name() string {
+	return "024_create_stream_tickets_table"
+}
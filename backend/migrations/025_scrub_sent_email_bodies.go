@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// ScrubSentEmailBodies миграция для удаления текста уже обработанных писем
+type ScrubSentEmailBodies struct{}
+
+// Up очищает текст отправленных и окончательно неотправленных писем:
+// в нем могли остаться одноразовые ссылки для сброса пароля
+func (m *ScrubSentEmailBodies) Up(tx *gorm.DB) error {
+	return tx.Exec(`UPDATE email_outbox SET body = '' WHERE status IN ('sent', 'dead') AND body <> ''`).Error
+}
+
+// Down ничего не делает: удаленный текст писем не восстанавливается
+func (m *ScrubSentEmailBodies) Down(tx *gorm.DB) error {
+	return nil
+}
+
+// Name возвращает имя миграции
+func (m *ScrubSentEmailBodies) Name() string {
+	return "025_scrub_sent_email_bodies"
+}
@@ -14,6 +14,26 @@ func GetMigrations() []Migrator {
 		&CreateDefectsTable{},
 		&CreateCommentsTable{},
 		&AddIndices{},
+		&CreateDefectHistoryTable{},
+		&CreateAttachmentsTable{},
+		&AddDefectSearch{},
+		&CreateRefreshTokensTable{},
+		&CreatePasswordResetTokensTable{},
+		&CreateProjectMembersTable{},
+		&CreateNotificationsTable{},
+		&CreateEmailOutboxTable{},
+		&CreateWebhooksTables{},
+		&AddDefectOverdue{},
+		&CreateProjectStagesTable{},
+		&CreateProjectLocationsTable{},
+		&CreateFloorPlansTable{},
+		&AddCoordinates{},
+		&AddCommentEdits{},
+		&AddCommentReplies{},
+		&AddVersions{},
+		&NullableAssigneeDueDate{},
+		&CreateStreamTicketsTable{},
+		&ScrubSentEmailBodies{},
 	}
 }
 
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// модель вложения (фото или файла) к дефекту
+type Attachment struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	DefectID     uint           `json:"defect_id"`
+	UploaderID   uint           `json:"uploader_id"`
+	Uploader     User           `json:"uploader" gorm:"foreignKey:UploaderID"`
+	FileName     string         `json:"file_name" gorm:"not null"`
+	MimeType     string         `json:"mime_type"`
+	Size         int64          `json:"size"`
+	SHA256       string         `json:"sha256" gorm:"column:sha256"`
+	StorageKey   string         `json:"-" gorm:"not null"`
+	ThumbnailKey string         `json:"-"`
+	HasThumbnail bool           `json:"has_thumbnail" gorm:"-"`
+	CreatedAt    time.Time      `json:"created_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// заполняет вычисляемые поля после загрузки из базы данных
+func (a *Attachment) AfterFind(tx *gorm.DB) error {
+	a.HasThumbnail = a.ThumbnailKey != ""
+	return nil
+}
@@ -6,19 +6,53 @@ import (
 	"gorm.io/gorm"
 )
 
-// модель комментария к дефекту
+// модель комментария к дефекту.
+// ParentID указывает на комментарий, ответом на который он является.
+// Удаленный комментарий, на который есть ответы, возвращается в списке
+// как заглушка (Deleted) без автора и текста.
 type Comment struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	DefectID  uint           `json:"defect_id"`
-	UserID    uint           `json:"user_id"`
-	User      User           `json:"user" gorm:"foreignKey:UserID"`
-	Content   string         `json:"content" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID         uint             `json:"id" gorm:"primaryKey"`
+	DefectID   uint             `json:"defect_id"`
+	ParentID   *uint            `json:"parent_id"`
+	UserID     uint             `json:"user_id,omitempty"`
+	User       *User            `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Content    string           `json:"content" gorm:"not null"`
+	Mentions   []CommentMention `json:"mentions" gorm:"foreignKey:CommentID"`
+	EditedAt   *time.Time       `json:"edited_at"`
+	ReplyCount int64            `json:"reply_count" gorm:"->"`
+	Replies    []Comment        `json:"replies,omitempty" gorm:"-"`
+	Deleted    bool             `json:"deleted,omitempty" gorm:"-"`
+	CreatedAt  time.Time        `json:"created_at"`
+	DeletedAt  gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+// упоминание пользователя (@username) в комментарии
+type CommentMention struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	CommentID uint      `json:"-"`
+	UserID    uint      `json:"user_id"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// прежняя редакция комментария, сохраняемая при каждом изменении
+type CommentRevision struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CommentID  uint      `json:"comment_id"`
+	Content    string    `json:"content" gorm:"not null"`
+	EditedByID uint      `json:"edited_by_id"`
+	EditedBy   *User     `json:"edited_by,omitempty" gorm:"foreignKey:EditedByID"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // данные для создания комментария
 type CommentCreate struct {
 	DefectID uint   `json:"defect_id" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
 	Content  string `json:"content" binding:"required"`
 }
+
+// данные для редактирования комментария
+type CommentUpdate struct {
+	Content string `json:"content" binding:"required"`
+}
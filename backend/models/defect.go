@@ -17,6 +17,63 @@ const (
 	DefectStatusCanceled   DefectStatus = "canceled"
 )
 
+// переход между статусами дефекта и роли, которым он разрешён
+type DefectTransition struct {
+	From  DefectStatus
+	To    DefectStatus
+	Roles []Role
+}
+
+// рабочий процесс дефекта: new → in_progress → review → closed/canceled,
+// возврат в работу возможен из review и closed
+var defectTransitions = []DefectTransition{
+	{From: DefectStatusNew, To: DefectStatusInProgress, Roles: []Role{RoleManager, RoleEngineer}},
+	{From: DefectStatusInProgress, To: DefectStatusReview, Roles: []Role{RoleManager, RoleEngineer}},
+	{From: DefectStatusReview, To: DefectStatusClosed, Roles: []Role{RoleManager}},
+	{From: DefectStatusReview, To: DefectStatusCanceled, Roles: []Role{RoleManager}},
+	{From: DefectStatusReview, To: DefectStatusInProgress, Roles: []Role{RoleManager, RoleEngineer}},
+	{From: DefectStatusClosed, To: DefectStatusInProgress, Roles: []Role{RoleManager}},
+}
+
+// статусы, в которых дефект больше не требует устранения
+var ClosedDefectStatuses = []DefectStatus{DefectStatusClosed, DefectStatusCanceled}
+
+// проверяет, что статус входит в список известных
+func (s DefectStatus) IsValid() bool {
+	switch s {
+	case DefectStatusNew, DefectStatusInProgress, DefectStatusReview, DefectStatusClosed, DefectStatusCanceled:
+		return true
+	}
+	return false
+}
+
+// возвращает статусы, в которые пользователь с ролью может перевести дефект
+func AllowedDefectTransitions(from DefectStatus, role Role) []DefectStatus {
+	allowed := []DefectStatus{}
+	for _, t := range defectTransitions {
+		if t.From != from {
+			continue
+		}
+		for _, r := range t.Roles {
+			if r == role {
+				allowed = append(allowed, t.To)
+				break
+			}
+		}
+	}
+	return allowed
+}
+
+// проверяет, разрешён ли переход статуса для роли
+func CanTransitionDefect(from, to DefectStatus, role Role) bool {
+	for _, s := range AllowedDefectTransitions(from, role) {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
 // приоритет дефекта
 type DefectPriority string
 
@@ -26,24 +83,50 @@ const (
 	DefectPriorityHigh   DefectPriority = "high"
 )
 
-// модель дефекта на строительном объекте
+// проверяет, что приоритет входит в список известных
+func (p DefectPriority) IsValid() bool {
+	switch p {
+	case DefectPriorityLow, DefectPriorityMedium, DefectPriorityHigh:
+		return true
+	}
+	return false
+}
+
+// модель дефекта на строительном объекте; OverdueSince выставляется планировщиком
+// контроля сроков, PinX/PinY — координаты метки на плане PlanID, нормированные
+// к размеру плана (0..1 от левого верхнего угла), Version увеличивается при каждом
+// изменении и используется как ETag для оптимистической блокировки
 type Defect struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Title       string         `json:"title" gorm:"not null"`
-	Description string         `json:"description"`
-	ProjectID   uint           `json:"project_id"`
-	Project     Project        `json:"project" gorm:"foreignKey:ProjectID"`
-	Status      DefectStatus   `json:"status" gorm:"type:varchar(20);default:'new'"`
-	Priority    DefectPriority `json:"priority" gorm:"type:varchar(10);default:'medium'"`
-	ReporterID  uint           `json:"reporter_id"`
-	Reporter    User           `json:"reporter" gorm:"foreignKey:ReporterID"`
-	AssigneeID  uint           `json:"assignee_id"`
-	Assignee    User           `json:"assignee" gorm:"foreignKey:AssigneeID"`
-	DueDate     time.Time      `json:"due_date"`
-	Comments    []Comment      `json:"comments" gorm:"foreignKey:DefectID"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	Title        string           `json:"title" gorm:"not null"`
+	Description  string           `json:"description"`
+	ProjectID    uint             `json:"project_id"`
+	Project      Project          `json:"project" gorm:"foreignKey:ProjectID"`
+	StageID      *uint            `json:"stage_id"`
+	Stage        *ProjectStage    `json:"stage,omitempty" gorm:"foreignKey:StageID"`
+	LocationID   *uint            `json:"location_id"`
+	Location     *ProjectLocation `json:"location,omitempty" gorm:"foreignKey:LocationID"`
+	PlanID       *uint            `json:"plan_id"`
+	PinX         *float64         `json:"pin_x"`
+	PinY         *float64         `json:"pin_y"`
+	Latitude     *float64         `json:"latitude"`
+	Longitude    *float64         `json:"longitude"`
+	Status       DefectStatus     `json:"status" gorm:"type:varchar(20);default:'new'"`
+	Priority     DefectPriority   `json:"priority" gorm:"type:varchar(10);default:'medium'"`
+	ReporterID   uint             `json:"reporter_id"`
+	Reporter     User             `json:"reporter" gorm:"foreignKey:ReporterID"`
+	AssigneeID   *uint            `json:"assignee_id"`
+	Assignee     *User            `json:"assignee" gorm:"foreignKey:AssigneeID"`
+	DueDate      *time.Time       `json:"due_date"`
+	OverdueSince *time.Time       `json:"overdue_since"`
+	Version      uint             `json:"version" gorm:"not null;default:1"`
+	Comments     []Comment        `json:"comments" gorm:"foreignKey:DefectID"`
+	Attachments  []Attachment     `json:"attachments,omitempty" gorm:"foreignKey:DefectID"`
+	SearchRank   float64          `json:"search_rank,omitempty" gorm:"->"`
+	Snippet      string           `json:"snippet,omitempty" gorm:"->"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt   `json:"-" gorm:"index"`
 }
 
 // данные для создания дефекта
@@ -51,6 +134,13 @@ type DefectCreate struct {
 	Title       string         `json:"title" binding:"required"`
 	Description string         `json:"description"`
 	ProjectID   uint           `json:"project_id" binding:"required"`
+	StageID     *uint          `json:"stage_id"`
+	LocationID  *uint          `json:"location_id"`
+	PlanID      *uint          `json:"plan_id"`
+	PinX        *float64       `json:"pin_x"`
+	PinY        *float64       `json:"pin_y"`
+	Latitude    *float64       `json:"latitude"`
+	Longitude   *float64       `json:"longitude"`
 	Priority    DefectPriority `json:"priority"`
 	AssigneeID  uint           `json:"assignee_id"`
 	DueDate     time.Time      `json:"due_date"`
@@ -64,4 +154,31 @@ type DefectUpdate struct {
 	Priority    DefectPriority `json:"priority"`
 	AssigneeID  uint           `json:"assignee_id"`
 	DueDate     time.Time      `json:"due_date"`
+	// 0 снимает привязку к этапу, месту или плану
+	StageID    *uint    `json:"stage_id"`
+	LocationID *uint    `json:"location_id"`
+	PlanID     *uint    `json:"plan_id"`
+	PinX       *float64 `json:"pin_x"`
+	PinY       *float64 `json:"pin_y"`
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+}
+
+// массовая операция над дефектами
+type BulkDefectAction string
+
+const (
+	BulkDefectUpdate BulkDefectAction = "update"
+	BulkDefectDelete BulkDefectAction = "delete"
+)
+
+// запрос массовой операции: изменение статуса, приоритета, исполнителя
+// и срока устранения либо удаление списка дефектов
+type DefectBulkRequest struct {
+	IDs        []uint           `json:"ids" binding:"required,min=1,max=500"`
+	Action     BulkDefectAction `json:"action" binding:"required"`
+	Status     DefectStatus     `json:"status"`
+	Priority   DefectPriority   `json:"priority"`
+	AssigneeID uint             `json:"assignee_id"`
+	DueDate    time.Time        `json:"due_date"`
 }
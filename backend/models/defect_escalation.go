@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// ступень эскалации просроченного дефекта
+type EscalationLevel string
+
+const (
+	// напоминание исполнителю о приближении срока
+	EscalationDueSoon EscalationLevel = "due_soon"
+	// напоминание исполнителю о просрочке
+	EscalationAssigneeReminder EscalationLevel = "assignee_reminder"
+	// уведомление менеджера проекта по истечении льготного периода
+	EscalationManager EscalationLevel = "manager_escalation"
+)
+
+// запись о выполненной ступени эскалации; одна ступень выполняется
+// один раз для каждого значения срока устранения
+type DefectEscalation struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	DefectID    uint            `json:"defect_id"`
+	Level       EscalationLevel `json:"level" gorm:"type:varchar(30);not null"`
+	DueDate     time.Time       `json:"due_date"`
+	RecipientID *uint           `json:"recipient_id"`
+	Recipient   *User           `json:"recipient,omitempty" gorm:"foreignKey:RecipientID"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// запись истории изменений дефекта
+type DefectHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	DefectID  uint      `json:"defect_id"`
+	UserID    uint      `json:"user_id"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+	Field     string    `json:"field" gorm:"not null"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// имя таблицы истории изменений дефектов
+func (DefectHistory) TableName() string {
+	return "defect_history"
+}
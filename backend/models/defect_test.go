@@ -0,0 +1,63 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllowedDefectTransitions(t *testing.T) {
+	tests := []struct {
+		from DefectStatus
+		role Role
+		want []DefectStatus
+	}{
+		{DefectStatusNew, RoleManager, []DefectStatus{DefectStatusInProgress}},
+		{DefectStatusNew, RoleEngineer, []DefectStatus{DefectStatusInProgress}},
+		{DefectStatusNew, RoleObserver, []DefectStatus{}},
+		{DefectStatusInProgress, RoleEngineer, []DefectStatus{DefectStatusReview}},
+		{DefectStatusReview, RoleManager, []DefectStatus{DefectStatusClosed, DefectStatusCanceled, DefectStatusInProgress}},
+		{DefectStatusReview, RoleEngineer, []DefectStatus{DefectStatusInProgress}},
+		{DefectStatusClosed, RoleManager, []DefectStatus{DefectStatusInProgress}},
+		{DefectStatusClosed, RoleEngineer, []DefectStatus{}},
+		{DefectStatusCanceled, RoleManager, []DefectStatus{}},
+		{DefectStatus("unknown"), RoleManager, []DefectStatus{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"/"+string(tt.role), func(t *testing.T) {
+			if got := AllowedDefectTransitions(tt.from, tt.role); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllowedDefectTransitions(%s, %s) = %v, ожидалось %v", tt.from, tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanTransitionDefect(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to DefectStatus
+		role     Role
+		want     bool
+	}{
+		{"инженер берет дефект в работу", DefectStatusNew, DefectStatusInProgress, RoleEngineer, true},
+		{"наблюдатель не меняет статус", DefectStatusNew, DefectStatusInProgress, RoleObserver, false},
+		{"нельзя перескочить проверку", DefectStatusNew, DefectStatusClosed, RoleManager, false},
+		{"инженер передает на проверку", DefectStatusInProgress, DefectStatusReview, RoleEngineer, true},
+		{"инженер не закрывает дефект", DefectStatusReview, DefectStatusClosed, RoleEngineer, false},
+		{"менеджер закрывает дефект", DefectStatusReview, DefectStatusClosed, RoleManager, true},
+		{"менеджер отменяет дефект", DefectStatusReview, DefectStatusCanceled, RoleManager, true},
+		{"инженер возвращает в работу с проверки", DefectStatusReview, DefectStatusInProgress, RoleEngineer, true},
+		{"менеджер переоткрывает закрытый", DefectStatusClosed, DefectStatusInProgress, RoleManager, true},
+		{"инженер не переоткрывает закрытый", DefectStatusClosed, DefectStatusInProgress, RoleEngineer, false},
+		{"отмененный дефект не переоткрывается", DefectStatusCanceled, DefectStatusInProgress, RoleManager, false},
+		{"переход в тот же статус не входит в процесс", DefectStatusNew, DefectStatusNew, RoleManager, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransitionDefect(tt.from, tt.to, tt.role); got != tt.want {
+				t.Errorf("CanTransitionDefect(%s, %s, %s) = %v, ожидалось %v", tt.from, tt.to, tt.role, got, tt.want)
+			}
+		})
+	}
+}
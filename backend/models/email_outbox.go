@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// состояние письма в очереди отправки
+type EmailStatus string
+
+const (
+	EmailStatusPending EmailStatus = "pending"
+	EmailStatusSent    EmailStatus = "sent"
+	EmailStatusDead    EmailStatus = "dead"
+)
+
+// письмо в транзакционной очереди (outbox), отправляемое фоновым обработчиком
+type EmailOutbox struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	Recipient     string      `json:"recipient" gorm:"not null"`
+	Subject       string      `json:"subject" gorm:"not null"`
+	Body          string      `json:"body" gorm:"not null"`
+	DefectID      *uint       `json:"defect_id"`
+	Status        EmailStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
+	Attempts      int         `json:"attempts"`
+	NextAttemptAt time.Time   `json:"next_attempt_at"`
+	LastError     string      `json:"last_error"`
+	SentAt        *time.Time  `json:"sent_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// имя таблицы очереди писем
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
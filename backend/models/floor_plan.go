@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// поэтажный план (изображение или страница PDF), привязанный к месту на объекте;
+// Page — номер страницы PDF, Width/Height — размеры изображения в пикселях
+type FloorPlan struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ProjectID    uint           `json:"project_id"`
+	LocationID   uint           `json:"location_id"`
+	Name         string         `json:"name" gorm:"not null"`
+	FileName     string         `json:"file_name" gorm:"not null"`
+	MimeType     string         `json:"mime_type" gorm:"not null"`
+	Size         int64          `json:"size"`
+	Page         int            `json:"page" gorm:"default:1"`
+	Width        int            `json:"width"`
+	Height       int            `json:"height"`
+	StorageKey   string         `json:"-" gorm:"not null"`
+	UploadedByID uint           `json:"uploaded_by_id"`
+	UploadedBy   User           `json:"uploaded_by" gorm:"foreignKey:UploadedByID"`
+	CreatedAt    time.Time      `json:"created_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// метка дефекта на плане
+type DefectPin struct {
+	DefectID uint           `json:"defect_id"`
+	Title    string         `json:"title"`
+	Status   DefectStatus   `json:"status"`
+	Priority DefectPriority `json:"priority"`
+	X        float64        `json:"x"`
+	Y        float64        `json:"y"`
+}
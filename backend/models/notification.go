@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// тип уведомления
+type NotificationType string
+
+const (
+	NotificationDefectAssigned       NotificationType = "defect_assigned"
+	NotificationDefectStatusChanged  NotificationType = "defect_status_changed"
+	NotificationDefectCommented      NotificationType = "defect_commented"
+	NotificationDefectDueDateChanged NotificationType = "defect_due_date_changed"
+	NotificationDefectDeleted        NotificationType = "defect_deleted"
+	NotificationDefectOverdue        NotificationType = "defect_overdue"
+	NotificationDefectDueSoon        NotificationType = "defect_due_soon"
+	NotificationDefectEscalated      NotificationType = "defect_escalated"
+	NotificationCommentMention       NotificationType = "comment_mention"
+)
+
+// уведомление пользователя во внутреннем почтовом ящике
+type Notification struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	UserID    uint             `json:"user_id"`
+	Type      NotificationType `json:"type" gorm:"type:varchar(50);not null"`
+	Message   string           `json:"message" gorm:"not null"`
+	DefectID  *uint            `json:"defect_id"`
+	ProjectID *uint            `json:"project_id"`
+	ActorID   *uint            `json:"actor_id"`
+	Actor     *User            `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
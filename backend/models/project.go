@@ -15,17 +15,30 @@ const (
 	ProjectStatusSuspended ProjectStatus = "suspended"
 )
 
+// проверяет, что статус входит в список известных
+func (s ProjectStatus) IsValid() bool {
+	switch s {
+	case ProjectStatusActive, ProjectStatusCompleted, ProjectStatusSuspended:
+		return true
+	}
+	return false
+}
+
 // модель строительного проекта/объекта
 type Project struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	Name        string         `json:"name" gorm:"not null"`
 	Description string         `json:"description"`
 	Location    string         `json:"location"`
+	Latitude    *float64       `json:"latitude"`
+	Longitude   *float64       `json:"longitude"`
 	StartDate   time.Time      `json:"start_date"`
 	EndDate     time.Time      `json:"end_date"`
 	Status      ProjectStatus  `json:"status" gorm:"type:varchar(20);default:'active'"`
 	ManagerID   uint           `json:"manager_id"`
 	Manager     User           `json:"manager" gorm:"foreignKey:ManagerID"`
+	Stages      []ProjectStage `json:"stages,omitempty" gorm:"foreignKey:ProjectID"`
+	Version     uint           `json:"version" gorm:"not null;default:1"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -36,6 +49,8 @@ type ProjectCreate struct {
 	Name        string        `json:"name" binding:"required"`
 	Description string        `json:"description"`
 	Location    string        `json:"location" binding:"required"`
+	Latitude    *float64      `json:"latitude"`
+	Longitude   *float64      `json:"longitude"`
 	StartDate   time.Time     `json:"start_date" binding:"required"`
 	EndDate     time.Time     `json:"end_date" binding:"required"`
 	Status      ProjectStatus `json:"status"`
@@ -47,6 +62,8 @@ type ProjectUpdate struct {
 	Name        string        `json:"name"`
 	Description string        `json:"description"`
 	Location    string        `json:"location"`
+	Latitude    *float64      `json:"latitude"`
+	Longitude   *float64      `json:"longitude"`
 	StartDate   time.Time     `json:"start_date"`
 	EndDate     time.Time     `json:"end_date"`
 	Status      ProjectStatus `json:"status"`
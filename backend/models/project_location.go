@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// тип узла иерархии мест на объекте
+type LocationKind string
+
+const (
+	LocationKindBuilding LocationKind = "building"
+	LocationKindSection  LocationKind = "section"
+	LocationKindFloor    LocationKind = "floor"
+	LocationKindRoom     LocationKind = "room"
+	LocationKindOther    LocationKind = "other"
+)
+
+// проверяет, что тип места входит в список известных
+func (k LocationKind) IsValid() bool {
+	switch k {
+	case LocationKindBuilding, LocationKindSection, LocationKindFloor, LocationKindRoom, LocationKindOther:
+		return true
+	}
+	return false
+}
+
+// место на объекте: узел дерева корпус → секция → этаж → помещение
+type ProjectLocation struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`
+	ProjectID uint              `json:"project_id"`
+	ParentID  *uint             `json:"parent_id"`
+	Name      string            `json:"name" gorm:"not null"`
+	Kind      LocationKind      `json:"kind" gorm:"type:varchar(20);default:'other'"`
+	Order     int               `json:"order" gorm:"column:sort_order"`
+	Children  []ProjectLocation `json:"children,omitempty" gorm:"-"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	DeletedAt gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+// данные для создания места
+type ProjectLocationCreate struct {
+	Name     string       `json:"name" binding:"required"`
+	Kind     LocationKind `json:"kind"`
+	ParentID *uint        `json:"parent_id"`
+	Order    int          `json:"order"`
+}
+
+// данные для обновления места
+type ProjectLocationUpdate struct {
+	Name string       `json:"name"`
+	Kind LocationKind `json:"kind"`
+	// 0 переносит место на верхний уровень
+	ParentID *uint `json:"parent_id"`
+	Order    *int  `json:"order"`
+}
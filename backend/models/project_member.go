@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// участник проекта с ролью в рамках проекта
+type ProjectMember struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProjectID uint      `json:"project_id"`
+	UserID    uint      `json:"user_id"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+	Role      Role      `json:"role" gorm:"type:varchar(20);default:'observer'"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// данные для добавления участника проекта
+type ProjectMemberCreate struct {
+	UserID uint `json:"user_id" binding:"required"`
+	Role   Role `json:"role"`
+}
+
+// данные для изменения роли участника проекта
+type ProjectMemberUpdate struct {
+	Role Role `json:"role" binding:"required"`
+}
+
+// проверяет, что роль входит в список известных
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleManager, RoleEngineer, RoleObserver:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// статус этапа проекта
+type StageStatus string
+
+const (
+	StageStatusPlanned    StageStatus = "planned"
+	StageStatusInProgress StageStatus = "in_progress"
+	StageStatusCompleted  StageStatus = "completed"
+)
+
+// проверяет, что статус этапа входит в список известных
+func (s StageStatus) IsValid() bool {
+	switch s {
+	case StageStatusPlanned, StageStatusInProgress, StageStatusCompleted:
+		return true
+	}
+	return false
+}
+
+// этап работ на проекте
+type ProjectStage struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ProjectID    uint           `json:"project_id"`
+	Name         string         `json:"name" gorm:"not null"`
+	Order        int            `json:"order" gorm:"column:sort_order"`
+	PlannedStart *time.Time     `json:"planned_start"`
+	PlannedEnd   *time.Time     `json:"planned_end"`
+	ActualStart  *time.Time     `json:"actual_start"`
+	ActualEnd    *time.Time     `json:"actual_end"`
+	Status       StageStatus    `json:"status" gorm:"type:varchar(20);default:'planned'"`
+	DefectCount  int64          `json:"defect_count" gorm:"->"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// данные для создания этапа проекта
+type ProjectStageCreate struct {
+	Name         string      `json:"name" binding:"required"`
+	Order        *int        `json:"order"`
+	PlannedStart *time.Time  `json:"planned_start"`
+	PlannedEnd   *time.Time  `json:"planned_end"`
+	ActualStart  *time.Time  `json:"actual_start"`
+	ActualEnd    *time.Time  `json:"actual_end"`
+	Status       StageStatus `json:"status"`
+}
+
+// данные для обновления этапа проекта
+type ProjectStageUpdate struct {
+	Name         string      `json:"name"`
+	Order        *int        `json:"order"`
+	PlannedStart *time.Time  `json:"planned_start"`
+	PlannedEnd   *time.Time  `json:"planned_end"`
+	ActualStart  *time.Time  `json:"actual_start"`
+	ActualEnd    *time.Time  `json:"actual_end"`
+	Status       StageStatus `json:"status"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// модель refresh-токена; токены одной сессии объединены в семейство
+type RefreshToken struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id"`
+	FamilyID     string     `json:"family_id" gorm:"not null"`
+	TokenHash    string     `json:"-" gorm:"not null;unique"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uint      `json:"replaced_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// данные запроса на обновление токенов или выход
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// одноразовый билет для подключения к потоку событий проекта: EventSource
+// не передает заголовки, поэтому вместо токена доступа в URL передается билет
+type StreamTicket struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	UserID         uint      `json:"user_id"`
+	ProjectID      uint      `json:"project_id"`
+	SessionID      string    `json:"-" gorm:"not null"`
+	TicketHash     string    `json:"-" gorm:"not null;unique"`
+	TokenExpiresAt time.Time `json:"-"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
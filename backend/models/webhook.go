@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// событие, на которое можно подписать вебхук
+type WebhookEvent string
+
+const (
+	EventDefectCreated       WebhookEvent = "defect.created"
+	EventDefectUpdated       WebhookEvent = "defect.updated"
+	EventDefectStatusChanged WebhookEvent = "defect.status_changed"
+	EventDefectDeleted       WebhookEvent = "defect.deleted"
+	EventCommentCreated      WebhookEvent = "comment.created"
+	EventCommentUpdated      WebhookEvent = "comment.updated"
+	EventCommentDeleted      WebhookEvent = "comment.deleted"
+	EventProjectCreated      WebhookEvent = "project.created"
+	EventProjectUpdated      WebhookEvent = "project.updated"
+	EventProjectDeleted      WebhookEvent = "project.deleted"
+)
+
+// все поддерживаемые события вебхуков
+var WebhookEvents = []WebhookEvent{
+	EventDefectCreated,
+	EventDefectUpdated,
+	EventDefectStatusChanged,
+	EventDefectDeleted,
+	EventCommentCreated,
+	EventCommentUpdated,
+	EventCommentDeleted,
+	EventProjectCreated,
+	EventProjectUpdated,
+	EventProjectDeleted,
+}
+
+// проверяет, что событие входит в список поддерживаемых
+func (e WebhookEvent) IsValid() bool {
+	for _, known := range WebhookEvents {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// состояние доставки вебхука
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending DeliveryStatus = "pending"
+	DeliveryStatusSuccess DeliveryStatus = "success"
+	DeliveryStatusDead    DeliveryStatus = "dead"
+)
+
+// внешний адрес, получающий события системы
+type Webhook struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	URL         string         `json:"url" gorm:"not null"`
+	Secret      string         `json:"-" gorm:"not null"`
+	Events      []WebhookEvent `json:"events" gorm:"serializer:json;not null"`
+	Active      bool           `json:"active" gorm:"default:true"`
+	CreatedByID uint           `json:"created_by_id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// проверяет, подписан ли вебхук на событие
+func (w *Webhook) Subscribed(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// попытка доставки события на вебхук (журнал доставок)
+type WebhookDelivery struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	WebhookID      uint            `json:"webhook_id"`
+	Event          WebhookEvent    `json:"event" gorm:"type:varchar(50);not null"`
+	Payload        json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Status         DeliveryStatus  `json:"status" gorm:"type:varchar(20);default:'pending'"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status"`
+	ResponseBody   string          `json:"response_body"`
+	LastError      string          `json:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	ReplayOfID     *uint           `json:"replay_of_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// данные для создания вебхука
+type WebhookCreate struct {
+	URL    string         `json:"url" binding:"required,url"`
+	Events []WebhookEvent `json:"events" binding:"required,min=1"`
+	Secret string         `json:"secret"`
+	Active *bool          `json:"active"`
+}
+
+// данные для обновления вебхука
+type WebhookUpdate struct {
+	URL    string         `json:"url" binding:"omitempty,url"`
+	Events []WebhookEvent `json:"events"`
+	Secret string         `json:"secret"`
+	Active *bool          `json:"active"`
+}
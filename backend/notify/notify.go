@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"systemControl_proj/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// создание уведомлений о событии дефекта для указанных получателей
+// и постановка писем в очередь, если событие отправляется по email
+func DefectUsers(tx *gorm.DB, defect *models.Defect, kind models.NotificationType, actorID uint, recipients []uint, message string) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	defectID, projectID := defect.ID, defect.ProjectID
+	var actor *uint
+	if actorID != 0 {
+		actor = &actorID
+	}
+
+	notifications := make([]models.Notification, len(recipients))
+	for i, userID := range recipients {
+		notifications[i] = models.Notification{
+			UserID:    userID,
+			Type:      kind,
+			Message:   message,
+			DefectID:  &defectID,
+			ProjectID: &projectID,
+			ActorID:   actor,
+		}
+	}
+	if err := tx.Create(&notifications).Error; err != nil {
+		return err
+	}
+
+	if !isEmailEvent(kind, defect) {
+		return nil
+	}
+	return enqueueEmails(tx, recipients, defect, message)
+}
+
+// события, о которых дополнительно сообщается по email
+func isEmailEvent(kind models.NotificationType, defect *models.Defect) bool {
+	switch kind {
+	case models.NotificationDefectAssigned, models.NotificationDefectCommented, models.NotificationCommentMention,
+		models.NotificationDefectOverdue, models.NotificationDefectDueSoon, models.NotificationDefectEscalated:
+		return true
+	case models.NotificationDefectStatusChanged:
+		return defect.Status == models.DefectStatusReview
+	}
+	return false
+}
+
+// максимальная длина темы письма (столбец email_outbox.subject)
+const maxEmailSubject = 255
+
+// тема письма по тексту уведомления; текст содержит название дефекта
+// и обрезается до длины столбца
+func emailSubject(message string) string {
+	subject := []rune("СистемаКонтроля: " + message)
+	if len(subject) <= maxEmailSubject {
+		return string(subject)
+	}
+	return string(subject[:maxEmailSubject-1]) + "…"
+}
+
+// постановка письма, не связанного с дефектом, в очередь отправки
+// в рамках текущей транзакции
+func Email(tx *gorm.DB, recipient, subject, body string) error {
+	return tx.Create(&models.EmailOutbox{
+		Recipient:     recipient,
+		Subject:       subject,
+		Body:          body,
+		Status:        models.EmailStatusPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// постановка писем в очередь отправки в рамках текущей транзакции
+func enqueueEmails(tx *gorm.DB, recipients []uint, defect *models.Defect, message string) error {
+	var users []models.User
+	if err := tx.Select("id", "email").Where("id IN ?", recipients).Find(&users).Error; err != nil {
+		return err
+	}
+
+	defectID := defect.ID
+	emails := []models.EmailOutbox{}
+	for _, user := range users {
+		if user.Email == "" {
+			continue
+		}
+		emails = append(emails, models.EmailOutbox{
+			Recipient:     user.Email,
+			Subject:       emailSubject(message),
+			Body:          message,
+			DefectID:      &defectID,
+			Status:        models.EmailStatusPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+	return tx.Create(&emails).Error
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"strings"
+	"systemControl_proj/models"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestIsEmailEvent(t *testing.T) {
+	tests := []struct {
+		kind   models.NotificationType
+		status models.DefectStatus
+		want   bool
+	}{
+		{models.NotificationDefectAssigned, models.DefectStatusNew, true},
+		{models.NotificationDefectCommented, models.DefectStatusInProgress, true},
+		{models.NotificationDefectEscalated, models.DefectStatusInProgress, true},
+		{models.NotificationDefectStatusChanged, models.DefectStatusReview, true},
+		{models.NotificationDefectStatusChanged, models.DefectStatusClosed, false},
+		{models.NotificationDefectDueDateChanged, models.DefectStatusNew, false},
+	}
+
+	for _, tt := range tests {
+		defect := &models.Defect{Status: tt.status}
+		if got := isEmailEvent(tt.kind, defect); got != tt.want {
+			t.Errorf("isEmailEvent(%s, %s) = %v, ожидалось %v", tt.kind, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestEmailSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		length  int
+		cut     bool
+	}{
+		{"короткий текст", "Дефект «Трещина» назначен исполнителю", utf8.RuneCountInString("СистемаКонтроля: Дефект «Трещина» назначен исполнителю"), false},
+		{"ровно по длине столбца", strings.Repeat("я", maxEmailSubject-len([]rune("СистемаКонтроля: "))), maxEmailSubject, false},
+		{"длинное название дефекта", "Дефект «" + strings.Repeat("ж", 400) + "» просрочен", maxEmailSubject, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := emailSubject(tt.message)
+			if !utf8.ValidString(subject) {
+				t.Fatalf("тема %q не в UTF-8", subject)
+			}
+			if got := utf8.RuneCountInString(subject); got != tt.length {
+				t.Errorf("длина темы %d символов, ожидалось %d", got, tt.length)
+			}
+			if strings.HasSuffix(subject, "…") != tt.cut {
+				t.Errorf("тема %q обрезана: %v, ожидалось %v", subject, !tt.cut, tt.cut)
+			}
+		})
+	}
+}
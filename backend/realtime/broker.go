@@ -0,0 +1,140 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"systemControl_proj/config"
+	"systemControl_proj/database"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// канал PostgreSQL, через который экземпляры бэкенда обмениваются событиями
+const Channel = "project_events"
+
+// размер буфера событий одного подписчика
+const subscriberBuffer = 32
+
+// событие проекта, передаваемое подписчикам потока
+type Event struct {
+	Event     string          `json:"event"`
+	ProjectID uint            `json:"project_id"`
+	ID        uint            `json:"id"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// рассылка событий проектов подписчикам текущего экземпляра;
+// события поступают через LISTEN/NOTIFY, поэтому их получают все экземпляры
+type Broker struct {
+	dsn string
+
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan Event]struct{}
+}
+
+var Default *Broker
+
+// создает брокер событий по настройкам приложения
+func SetupBroker(cfg *config.Config) *Broker {
+	Default = NewBroker(database.DSN(cfg))
+	return Default
+}
+
+// создает брокер, слушающий события по строке подключения dsn
+func NewBroker(dsn string) *Broker {
+	return &Broker{
+		dsn:         dsn,
+		subscribers: make(map[uint]map[chan Event]struct{}),
+	}
+}
+
+// подписка на события проекта; возвращает канал событий и функцию отписки
+func (b *Broker) Subscribe(projectID uint) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[projectID] == nil {
+		b.subscribers[projectID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[projectID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[projectID], ch)
+			if len(b.subscribers[projectID]) == 0 {
+				delete(b.subscribers, projectID)
+			}
+			b.mu.Unlock()
+		})
+	}
+	return ch, unsubscribe
+}
+
+// рассылает событие подписчикам проекта; медленные подписчики пропускают событие
+func (b *Broker) publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[event.ProjectID] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Подписчик проекта %d не успевает получать события, событие %s пропущено", event.ProjectID, event.Event)
+		}
+	}
+}
+
+// слушает канал PostgreSQL до отмены контекста, переподключаясь при ошибках
+func (b *Broker) Run(ctx context.Context) {
+	delay := time.Second
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Ошибка получения событий из PostgreSQL: %v, повтор через %s", err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay < time.Minute {
+			delay *= 2
+		}
+	}
+}
+
+// держит отдельное соединение с LISTEN и рассылает полученные уведомления
+func (b *Broker) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	log.Printf("Подписка на канал событий %s установлена", Channel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Printf("Некорректное событие в канале %s: %v", Channel, err)
+			continue
+		}
+		b.publish(event)
+	}
+}
@@ -13,16 +13,24 @@ import (
 // настраивает маршруты для API
 func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 	userController := controllers.NewUserController(cfg)
-	projectController := controllers.NewProjectController()
-	defectController := controllers.NewDefectController()
+	projectController := controllers.NewProjectController(cfg)
+	defectController := controllers.NewDefectController(cfg)
 	commentController := controllers.NewCommentController()
+	attachmentController := controllers.NewAttachmentController(cfg)
+	reportController := controllers.NewReportController()
+	notificationController := controllers.NewNotificationController()
+	webhookController := controllers.NewWebhookController(cfg)
+	stageController := controllers.NewStageController()
+	locationController := controllers.NewLocationController()
+	eventStreamController := controllers.NewEventStreamController()
 	debugController := controllers.NewDebugController(cfg) // Отладочный контроллер
 
 	// Middleware для CORS
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, If-Match")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -43,46 +51,57 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 	{
 		auth.POST("/register", userController.Register)
 		auth.POST("/login", userController.Login)
+		auth.POST("/refresh", userController.Refresh)
+		auth.POST("/logout", userController.Logout)
+		auth.POST("/forgot-password", userController.ForgotPassword)
+		auth.POST("/reset-password", userController.ResetPassword)
 	}
 
-	// Отладочные маршруты
-	debug := router.Group("/debug")
-	{
-		// Получение списка всех пользователей
-		debug.GET("/users", func(c *gin.Context) {
-			var users []models.User
-			if err := database.DB.Find(&users).Error; err != nil {
-				c.JSON(500, gin.H{"error": "Ошибка получения пользователей", "details": err.Error()})
-				return
-			}
-
-			// Создаем безопасные для передачи объекты (без хешей паролей)
-			safeUsers := make([]gin.H, len(users))
-			for i, user := range users {
-				safeUsers[i] = gin.H{
-					"id":          user.ID,
-					"username":    user.Username,
-					"email":       user.Email,
-					"full_name":   user.FullName,
-					"role":        user.Role,
-					"created_at":  user.CreatedAt,
-					"hash_length": len(user.PasswordHash),
+	// Отладочные маршруты (включаются только через ENABLE_DEBUG_ROUTES=true)
+	if cfg.Server.DebugRoutes {
+		debug := router.Group("/debug")
+		{
+			// Получение списка всех пользователей
+			debug.GET("/users", func(c *gin.Context) {
+				var users []models.User
+				if err := database.DB.Find(&users).Error; err != nil {
+					c.JSON(500, gin.H{"error": "Ошибка получения пользователей", "details": err.Error()})
+					return
 				}
-			}
 
-			c.JSON(200, gin.H{
-				"users": safeUsers,
-				"count": len(users),
+				// Создаем безопасные для передачи объекты (без хешей паролей)
+				safeUsers := make([]gin.H, len(users))
+				for i, user := range users {
+					safeUsers[i] = gin.H{
+						"id":          user.ID,
+						"username":    user.Username,
+						"email":       user.Email,
+						"full_name":   user.FullName,
+						"role":        user.Role,
+						"created_at":  user.CreatedAt,
+						"hash_length": len(user.PasswordHash),
+					}
+				}
+
+				c.JSON(200, gin.H{
+					"users": safeUsers,
+					"count": len(users),
+				})
 			})
-		})
 
-		// Сброс пароля для пользователя
-		debug.POST("/reset-password", debugController.ResetUserPassword)
+			// Сброс пароля для пользователя
+			debug.POST("/reset-password", debugController.ResetUserPassword)
 
-		// Создание тестового пользователя
-		debug.POST("/create-test-user", debugController.CreateTestUser)
+			// Создание тестового пользователя
+			debug.POST("/create-test-user", debugController.CreateTestUser)
+		}
 	}
 
+	// поток событий проекта (SSE); вместо заголовка Authorization допускается
+	// одноразовый билет в параметре ticket, поэтому маршрут регистрируется
+	// вне группы /api с обычной проверкой токена
+	router.GET("/api/projects/:id/events", middleware.StreamAuthMiddleware(cfg), eventStreamController.StreamProjectEvents)
+
 	// маршруты, требующие аутентификации
 	api := router.Group("/api")
 	api.Use(middleware.AuthMiddleware(cfg))
@@ -96,6 +115,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 			// Доступно менеджерам и инженерам: получить список инженеров
 			users.GET("/engineers", middleware.RoleMiddleware(models.RoleManager, models.RoleEngineer), userController.GetEngineers)
 			users.PUT("/:id/role", middleware.RoleMiddleware(models.RoleManager), userController.UpdateUserRole)
+			users.POST("/:id/revoke-sessions", middleware.RoleMiddleware(models.RoleManager), userController.RevokeUserSessions)
 		}
 
 		projects := api.Group("/projects")
@@ -103,10 +123,75 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 			projects.GET("", projectController.GetAllProjects)
 			projects.GET("/:id", projectController.GetProject)
 			projects.GET("/:id/defects", defectController.GetAllDefects)
+			projects.POST("/:id/events/ticket", eventStreamController.CreateStreamTicket)
+			projects.POST("/:id/defects/import", defectController.ImportDefects)
 			projects.POST("", middleware.RoleMiddleware(models.RoleManager), projectController.CreateProject)
 			projects.PUT("/:id", middleware.RoleMiddleware(models.RoleManager), projectController.UpdateProject)
+			projects.PATCH("/:id", middleware.RoleMiddleware(models.RoleManager), projectController.PatchProject)
 			projects.DELETE("/:id", middleware.RoleMiddleware(models.RoleManager), projectController.DeleteProject)
+
+			// участники проекта
+			projects.GET("/:id/members", projectController.GetProjectMembers)
+			projects.POST("/:id/members", middleware.RoleMiddleware(models.RoleManager), projectController.AddProjectMember)
+			projects.PUT("/:id/members/:user_id", middleware.RoleMiddleware(models.RoleManager), projectController.UpdateProjectMember)
+			projects.DELETE("/:id/members/:user_id", middleware.RoleMiddleware(models.RoleManager), projectController.RemoveProjectMember)
+
+			// этапы проекта
+			projects.GET("/:id/stages", stageController.GetStages)
+			projects.POST("/:id/stages", middleware.RoleMiddleware(models.RoleManager), stageController.CreateStage)
+			projects.PUT("/:id/stages/:stage_id", middleware.RoleMiddleware(models.RoleManager), stageController.UpdateStage)
+			projects.DELETE("/:id/stages/:stage_id", middleware.RoleMiddleware(models.RoleManager), stageController.DeleteStage)
+
+			// иерархия мест на объекте
+			projects.GET("/:id/locations", locationController.GetLocations)
+			projects.GET("/:id/locations/:location_id", locationController.GetLocation)
+			projects.POST("/:id/locations", middleware.RoleMiddleware(models.RoleManager), locationController.CreateLocation)
+			projects.PUT("/:id/locations/:location_id", middleware.RoleMiddleware(models.RoleManager), locationController.UpdateLocation)
+			projects.DELETE("/:id/locations/:location_id", middleware.RoleMiddleware(models.RoleManager), locationController.DeleteLocation)
+
+			// планы мест и метки дефектов на них
+			projects.GET("/:id/locations/:location_id/plans", projectController.GetFloorPlans)
+			projects.POST("/:id/locations/:location_id/plans", middleware.RoleMiddleware(models.RoleManager), projectController.UploadFloorPlan)
+			projects.GET("/:id/plans/:plan_id", projectController.GetFloorPlan)
+			projects.GET("/:id/plans/:plan_id/file", projectController.DownloadFloorPlan)
+			projects.GET("/:id/plans/:plan_id/pins", projectController.GetPlanPins)
+			projects.DELETE("/:id/plans/:plan_id", middleware.RoleMiddleware(models.RoleManager), projectController.DeleteFloorPlan)
+		}
+		// уведомления текущего пользователя
+		notifications := api.Group("/notifications")
+		{
+			notifications.GET("", notificationController.GetNotifications)
+			notifications.GET("/unread-count", notificationController.GetUnreadCount)
+			notifications.POST("/read-all", notificationController.MarkAllRead)
+			notifications.POST("/:id/read", notificationController.MarkRead)
 		}
+
+		// исходящие вебхуки (только менеджеры)
+		webhooks := api.Group("/webhooks")
+		webhooks.Use(middleware.RoleMiddleware(models.RoleManager))
+		{
+			webhooks.GET("/events", webhookController.GetWebhookEvents)
+			webhooks.GET("", webhookController.GetWebhooks)
+			webhooks.POST("", webhookController.CreateWebhook)
+			webhooks.GET("/:id", webhookController.GetWebhook)
+			webhooks.PUT("/:id", webhookController.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", webhookController.GetDeliveries)
+			webhooks.GET("/:id/deliveries/:delivery_id", webhookController.GetDelivery)
+			webhooks.POST("/:id/deliveries/:delivery_id/replay", webhookController.ReplayDelivery)
+		}
+
+		// аналитические отчёты (менеджеры и наблюдатели)
+		reports := api.Group("/reports")
+		reports.Use(middleware.RoleMiddleware(models.RoleManager, models.RoleObserver))
+		{
+			reports.GET("/defects/by-status", reportController.DefectsByStatus)
+			reports.GET("/defects/by-priority", reportController.DefectsByPriority)
+			reports.GET("/defects/by-project", reportController.DefectsByProject)
+			reports.GET("/defects/by-assignee", reportController.DefectsByAssignee)
+			reports.GET("/defects/by-week", reportController.DefectsByWeek)
+		}
+
 		// маршруты для дефектов
 		defects := api.Group("/defects")
 		{
@@ -114,13 +199,27 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 			defects.GET("/:id/comments", commentController.GetDefectComments)
 
 			defects.GET("", defectController.GetAllDefects)
+			defects.GET("/export", defectController.ExportDefects)
 			defects.GET("/:id", defectController.GetDefect)
+			defects.GET("/:id/history", defectController.GetDefectHistory)
+			defects.GET("/:id/escalations", defectController.GetDefectEscalations)
 			defects.POST("", defectController.CreateDefect)
+			defects.POST("/bulk", defectController.BulkDefects)
 			defects.PUT("/:id", defectController.UpdateDefect)
+			defects.PATCH("/:id", defectController.PatchDefect)
 			defects.DELETE("/:id", middleware.RoleMiddleware(models.RoleManager, models.RoleEngineer), defectController.DeleteDefect)
 
+			// вложения к дефектам
+			defects.GET("/:id/attachments", attachmentController.GetDefectAttachments)
+			defects.POST("/:id/attachments", attachmentController.UploadAttachment)
+			defects.GET("/:id/attachments/:attachment_id", attachmentController.DownloadAttachment)
+			defects.GET("/:id/attachments/:attachment_id/thumbnail", attachmentController.GetAttachmentThumbnail)
+			defects.DELETE("/:id/attachments/:attachment_id", middleware.RoleMiddleware(models.RoleManager, models.RoleEngineer), attachmentController.DeleteAttachment)
+
 			// комментарии к дефектам
 			defects.POST("/comments", commentController.CreateComment)
+			defects.PUT("/comments/:id", commentController.UpdateComment)
+			defects.GET("/comments/:id/revisions", commentController.GetCommentRevisions)
 			defects.DELETE("/comments/:id", commentController.DeleteComment)
 		}
 	}
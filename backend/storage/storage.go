@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"systemControl_proj/config"
+)
+
+// ошибка: файл не найден в хранилище
+var ErrNotFound = errors.New("файл не найден в хранилище")
+
+// хранилище файлов вложений
+type Storage interface {
+	// сохраняет содержимое под указанным ключом и возвращает размер в байтах
+	Save(key string, r io.Reader) (int64, error)
+	// открывает файл по ключу для чтения
+	Open(key string) (io.ReadCloser, error)
+	// удаляет файл по ключу
+	Delete(key string) error
+}
+
+var Files Storage
+
+// инициализирует хранилище файлов
+func SetupStorage(config *config.Config) (Storage, error) {
+	files, err := NewLocalStorage(config.Storage.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	Files = files
+
+	return files, nil
+}
@@ -0,0 +1,15 @@
+package workers
+
+import "time"
+
+// задержка перед следующей попыткой: base удваивается с каждой неудачей, но не больше max
+func backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
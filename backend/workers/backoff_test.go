@@ -0,0 +1,32 @@
+package workers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		max      time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{"первая неудача", 30 * time.Second, time.Hour, 1, 30 * time.Second},
+		{"вторая неудача", 30 * time.Second, time.Hour, 2, time.Minute},
+		{"пятая неудача", 30 * time.Second, time.Hour, 5, 8 * time.Minute},
+		{"упор в максимум", 30 * time.Second, time.Hour, 8, time.Hour},
+		{"много неудач без переполнения", 30 * time.Second, 6 * time.Hour, 200, 6 * time.Hour},
+		{"база больше максимума", 2 * time.Hour, time.Hour, 1, time.Hour},
+		{"попыток еще не было", time.Minute, time.Hour, 0, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoff(tt.base, tt.max, tt.attempts); got != tt.want {
+				t.Errorf("backoff(%s, %s, %d) = %s, ожидалось %s", tt.base, tt.max, tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
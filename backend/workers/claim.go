@@ -0,0 +1,26 @@
+package workers
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// захват порции готовых к обработке записей очереди (email_outbox,
+// webhook_deliveries) одним коротким запросом: next_attempt_at сдвигается
+// на lease, поэтому другие обработчики не возьмут эти записи, пока идет
+// отправка, а после сбоя процесса записи вернутся в очередь по истечении lease.
+// Отправка выполняется уже вне транзакции, без удерживаемых блокировок.
+func claimBatch(db *gorm.DB, table string, status interface{}, limit int, lease time.Duration, dest interface{}) error {
+	now := time.Now()
+	return db.Raw(`
+		UPDATE `+table+` SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM `+table+`
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), status, now, limit).Scan(dest).Error
+}
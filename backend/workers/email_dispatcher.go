@@ -0,0 +1,137 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"systemControl_proj/config"
+	"systemControl_proj/mailer"
+	"systemControl_proj/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// фоновая отправка писем из очереди email_outbox
+type EmailDispatcher struct {
+	DB           *gorm.DB
+	Mailer       mailer.Mailer
+	PublicURL    string
+	PollInterval time.Duration
+	MaxAttempts  int
+	BatchSize    int
+	// базовая задержка повторной попытки, удваивается с каждой неудачей
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// на сколько захваченные письма скрываются от других обработчиков
+	ClaimLease time.Duration
+}
+
+// создает обработчик очереди писем по настройкам приложения
+func NewEmailDispatcher(db *gorm.DB, m mailer.Mailer, cfg *config.Config) *EmailDispatcher {
+	d := &EmailDispatcher{
+		DB:           db,
+		Mailer:       m,
+		PublicURL:    strings.TrimRight(cfg.Server.PublicURL, "/"),
+		PollInterval: time.Duration(cfg.Mail.OutboxPollSeconds) * time.Second,
+		MaxAttempts:  cfg.Mail.OutboxMaxAttempts,
+		BatchSize:    20,
+		RetryBase:    30 * time.Second,
+		RetryMax:     time.Hour,
+	}
+	// каждое письмо отправляется не дольше SMTP_TIMEOUT_SECONDS
+	d.ClaimLease = time.Duration(d.BatchSize*cfg.Mail.SMTPTimeoutSeconds)*time.Second + time.Minute
+	return d
+}
+
+// запускает периодическую обработку очереди до отмены контекста
+func (d *EmailDispatcher) Run(ctx context.Context) {
+	log.Printf("Запуск отправки писем из очереди (интервал %s)", d.PollInterval)
+
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := d.processBatch()
+			if err != nil {
+				log.Printf("Ошибка обработки очереди писем: %v", err)
+				break
+			}
+			if processed < d.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// обрабатывает одну порцию писем, готовых к отправке: письма захватываются
+// коротким запросом, отправляются вне транзакции, а результат каждой попытки
+// сохраняется отдельным обновлением
+func (d *EmailDispatcher) processBatch() (int, error) {
+	var emails []models.EmailOutbox
+	if err := claimBatch(d.DB, models.EmailOutbox{}.TableName(), models.EmailStatusPending, d.BatchSize, d.ClaimLease, &emails); err != nil {
+		return 0, err
+	}
+
+	for i := range emails {
+		if err := d.deliver(&emails[i]); err != nil {
+			// письмо вернется в очередь по истечении ClaimLease
+			log.Printf("Ошибка сохранения результата отправки письма %d: %v", emails[i].ID, err)
+		}
+	}
+	return len(emails), nil
+}
+
+// отправляет письмо и сохраняет результат попытки
+func (d *EmailDispatcher) deliver(email *models.EmailOutbox) error {
+	body := email.Body
+	if email.DefectID != nil {
+		body += fmt.Sprintf("\n\nОткрыть дефект: %s/defects/%d", d.PublicURL, *email.DefectID)
+	}
+
+	sendErr := d.Mailer.Send(mailer.Message{
+		To:      email.Recipient,
+		Subject: email.Subject,
+		Body:    body,
+	})
+
+	email.Attempts++
+	updates := d.attemptUpdates(email.Attempts, sendErr, time.Now())
+	if updates["status"] == models.EmailStatusDead {
+		log.Printf("Письмо %d для %s не отправлено после %d попыток: %v", email.ID, email.Recipient, email.Attempts, sendErr)
+	}
+
+	return d.DB.Model(&models.EmailOutbox{}).Where("id = ?", email.ID).Updates(updates).Error
+}
+
+// изменения письма по результату attempts-й попытки отправки: отправлено,
+// исчерпан лимит попыток или повтор с увеличивающейся задержкой
+func (d *EmailDispatcher) attemptUpdates(attempts int, sendErr error, now time.Time) map[string]interface{} {
+	updates := map[string]interface{}{"attempts": attempts}
+
+	// текст отправленного или окончательно неотправленного письма не хранится:
+	// он может содержать одноразовые ссылки (например, для сброса пароля)
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.EmailStatusSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+		updates["body"] = ""
+	case attempts >= d.MaxAttempts:
+		updates["status"] = models.EmailStatusDead
+		updates["last_error"] = sendErr.Error()
+		updates["body"] = ""
+	default:
+		updates["next_attempt_at"] = now.Add(backoff(d.RetryBase, d.RetryMax, attempts))
+		updates["last_error"] = sendErr.Error()
+	}
+	return updates
+}
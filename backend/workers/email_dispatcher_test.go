@@ -0,0 +1,52 @@
+package workers
+
+import (
+	"errors"
+	"systemControl_proj/models"
+	"testing"
+	"time"
+)
+
+func TestEmailAttemptUpdates(t *testing.T) {
+	d := &EmailDispatcher{MaxAttempts: 3, RetryBase: 30 * time.Second, RetryMax: time.Hour}
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	smtpErr := errors.New("454 временная ошибка сервера")
+
+	tests := []struct {
+		name      string
+		attempts  int
+		err       error
+		status    interface{}
+		nextAt    interface{}
+		bodyWiped bool
+	}{
+		{"отправлено с первой попытки", 1, nil, models.EmailStatusSent, nil, true},
+		{"отправлено после повторов", 3, nil, models.EmailStatusSent, nil, true},
+		{"первая неудача", 1, smtpErr, nil, now.Add(30 * time.Second), false},
+		{"вторая неудача", 2, smtpErr, nil, now.Add(time.Minute), false},
+		{"лимит попыток исчерпан", 3, smtpErr, models.EmailStatusDead, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates := d.attemptUpdates(tt.attempts, tt.err, now)
+
+			if updates["attempts"] != tt.attempts {
+				t.Errorf("attempts = %v, ожидалось %d", updates["attempts"], tt.attempts)
+			}
+			if updates["status"] != tt.status {
+				t.Errorf("status = %v, ожидался %v", updates["status"], tt.status)
+			}
+			if updates["next_attempt_at"] != tt.nextAt {
+				t.Errorf("next_attempt_at = %v, ожидалось %v", updates["next_attempt_at"], tt.nextAt)
+			}
+			body, wiped := updates["body"]
+			if wiped != tt.bodyWiped || (wiped && body != "") {
+				t.Errorf("body = %v (изменяется: %v), ожидалась очистка: %v", body, wiped, tt.bodyWiped)
+			}
+			if tt.err != nil && updates["last_error"] != tt.err.Error() {
+				t.Errorf("last_error = %v, ожидалось %q", updates["last_error"], tt.err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"systemControl_proj/config"
+	"systemControl_proj/models"
+	"systemControl_proj/notify"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// условие наличия срока устранения
+const defectHasDueDate = "defects.due_date IS NOT NULL"
+
+// фоновый контроль сроков устранения: напоминания исполнителю и эскалация менеджеру проекта
+type OverdueScheduler struct {
+	DB           *gorm.DB
+	Interval     time.Duration
+	RemindBefore time.Duration
+	Grace        time.Duration
+	BatchSize    int
+}
+
+// создает планировщик контроля сроков по настройкам приложения
+func NewOverdueScheduler(db *gorm.DB, cfg *config.Config) *OverdueScheduler {
+	return &OverdueScheduler{
+		DB:           db,
+		Interval:     time.Duration(cfg.Overdue.CheckIntervalMinutes) * time.Minute,
+		RemindBefore: time.Duration(cfg.Overdue.RemindBeforeHours) * time.Hour,
+		Grace:        time.Duration(cfg.Overdue.EscalationGraceHours) * time.Hour,
+		BatchSize:    50,
+	}
+}
+
+// запускает периодическую проверку сроков до отмены контекста
+func (s *OverdueScheduler) Run(ctx context.Context) {
+	log.Printf("Запуск контроля сроков устранения дефектов (интервал %s)", s.Interval)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.check(time.Now()); err != nil {
+			log.Printf("Ошибка контроля сроков устранения дефектов: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// обновляет отметки просрочки и выполняет назревшие ступени эскалации
+func (s *OverdueScheduler) check(now time.Time) error {
+	// снятие отметки с закрытых дефектов и дефектов с перенесенным сроком
+	if err := s.DB.Model(&models.Defect{}).
+		Where("overdue_since IS NOT NULL").
+		Where("status IN ? OR NOT ("+defectHasDueDate+") OR due_date > ?", models.ClosedDefectStatuses, now).
+		UpdateColumn("overdue_since", nil).Error; err != nil {
+		return err
+	}
+
+	// отметка просроченных открытых дефектов
+	if err := s.DB.Model(&models.Defect{}).
+		Where("overdue_since IS NULL AND status NOT IN ?", models.ClosedDefectStatuses).
+		Where(defectHasDueDate+" AND due_date <= ?", now).
+		UpdateColumn("overdue_since", gorm.Expr("due_date")).Error; err != nil {
+		return err
+	}
+
+	for {
+		processed, err := s.processBatch(now)
+		if err != nil {
+			return err
+		}
+		if processed < s.BatchSize {
+			return nil
+		}
+	}
+}
+
+// обрабатывает одну порцию дефектов, для которых назрела очередная ступень эскалации
+func (s *OverdueScheduler) processBatch(now time.Time) (int, error) {
+	processed := 0
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		escalated := func(level models.EscalationLevel, extra string) string {
+			return "EXISTS (SELECT 1 FROM defect_escalations e WHERE e.defect_id = defects.id AND e.due_date = defects.due_date AND e.level = '" + string(level) + "'" + extra + ")"
+		}
+
+		var defects []models.Defect
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status NOT IN ?", models.ClosedDefectStatuses).
+			Where(defectHasDueDate).
+			Where(
+				tx.Where("due_date > ? AND due_date <= ? AND NOT "+escalated(models.EscalationDueSoon, ""), now, now.Add(s.RemindBefore)).
+					Or("due_date <= ? AND NOT "+escalated(models.EscalationAssigneeReminder, ""), now).
+					Or("due_date <= ? AND "+escalated(models.EscalationAssigneeReminder, " AND e.created_at <= ?")+" AND NOT "+escalated(models.EscalationManager, ""), now, now.Add(-s.Grace)),
+			).
+			Order("due_date, id").
+			Limit(s.BatchSize).
+			Find(&defects).Error; err != nil {
+			return err
+		}
+
+		for i := range defects {
+			if err := s.escalate(tx, &defects[i], now); err != nil {
+				return err
+			}
+			processed++
+		}
+		return nil
+	})
+
+	return processed, err
+}
+
+// выполняет очередную ступень эскалации дефекта (выбираются только дефекты со сроком)
+func (s *OverdueScheduler) escalate(tx *gorm.DB, defect *models.Defect, now time.Time) error {
+	dueDate := defect.DueDate.Format("02.01.2006")
+
+	// напоминание о просрочке для текущего значения срока, если уже отправлялось
+	var reminderAt *time.Time
+	if !defect.DueDate.After(now) {
+		var reminders []models.DefectEscalation
+		if err := tx.Where("defect_id = ? AND due_date = ? AND level = ?", defect.ID, *defect.DueDate, models.EscalationAssigneeReminder).
+			Limit(1).
+			Find(&reminders).Error; err != nil {
+			return err
+		}
+		if len(reminders) > 0 {
+			reminderAt = &reminders[0].CreatedAt
+		}
+	}
+
+	for _, level := range s.escalationSteps(*defect.DueDate, defect.AssigneeID != nil, reminderAt, now) {
+		var err error
+		switch level {
+		case models.EscalationDueSoon:
+			message := fmt.Sprintf("Срок устранения дефекта «%s» истекает %s", defect.Title, dueDate)
+			err = s.remindAssignee(tx, defect, models.EscalationDueSoon, models.NotificationDefectDueSoon, message)
+		case models.EscalationAssigneeReminder:
+			message := fmt.Sprintf("Дефект «%s» просрочен: срок устранения истёк %s", defect.Title, dueDate)
+			err = s.remindAssignee(tx, defect, models.EscalationAssigneeReminder, models.NotificationDefectOverdue, message)
+		case models.EscalationManager:
+			err = s.escalateToManager(tx, defect, dueDate)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ступени эскалации, назревшие к моменту now: до срока — напоминание о его
+// приближении, после срока — напоминание исполнителю, а по истечении льготного
+// периода после напоминания — уведомление менеджера. Без исполнителя напоминать
+// некому, поэтому менеджер уведомляется сразу
+func (s *OverdueScheduler) escalationSteps(dueDate time.Time, hasAssignee bool, reminderAt *time.Time, now time.Time) []models.EscalationLevel {
+	switch {
+	case dueDate.After(now):
+		return []models.EscalationLevel{models.EscalationDueSoon}
+	case reminderAt == nil && hasAssignee:
+		return []models.EscalationLevel{models.EscalationAssigneeReminder}
+	case reminderAt == nil:
+		return []models.EscalationLevel{models.EscalationAssigneeReminder, models.EscalationManager}
+	case reminderAt.Add(s.Grace).After(now):
+		return nil
+	}
+	return []models.EscalationLevel{models.EscalationManager}
+}
+
+// напоминание исполнителю дефекта с записью ступени эскалации
+func (s *OverdueScheduler) remindAssignee(tx *gorm.DB, defect *models.Defect, level models.EscalationLevel, kind models.NotificationType, message string) error {
+	if defect.AssigneeID == nil {
+		return recordEscalation(tx, defect, level, nil)
+	}
+
+	assigneeID := *defect.AssigneeID
+	if err := notify.DefectUsers(tx, defect, kind, 0, []uint{assigneeID}, message); err != nil {
+		return err
+	}
+	return recordEscalation(tx, defect, level, &assigneeID)
+}
+
+// уведомление менеджера проекта о просроченном дефекте
+func (s *OverdueScheduler) escalateToManager(tx *gorm.DB, defect *models.Defect, dueDate string) error {
+	var project models.Project
+	if err := tx.Unscoped().Select("id", "manager_id").First(&project, defect.ProjectID).Error; err != nil {
+		return err
+	}
+
+	assignee := "не назначен"
+	if defect.AssigneeID != nil {
+		var user models.User
+		if err := tx.Select("id", "username", "full_name").First(&user, *defect.AssigneeID).Error; err == nil {
+			assignee = user.Username
+			if user.FullName != "" {
+				assignee = user.FullName
+			}
+		}
+	}
+
+	message := fmt.Sprintf("Дефект «%s» не устранён в срок (%s), исполнитель: %s", defect.Title, dueDate, assignee)
+	managerID := project.ManagerID
+	if err := notify.DefectUsers(tx, defect, models.NotificationDefectEscalated, 0, []uint{managerID}, message); err != nil {
+		return err
+	}
+	return recordEscalation(tx, defect, models.EscalationManager, &managerID)
+}
+
+// запись выполненной ступени эскалации
+func recordEscalation(tx *gorm.DB, defect *models.Defect, level models.EscalationLevel, recipientID *uint) error {
+	return tx.Create(&models.DefectEscalation{
+		DefectID:    defect.ID,
+		Level:       level,
+		DueDate:     *defect.DueDate,
+		RecipientID: recipientID,
+	}).Error
+}
@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"reflect"
+	"systemControl_proj/models"
+	"testing"
+	"time"
+)
+
+func TestEscalationSteps(t *testing.T) {
+	s := &OverdueScheduler{RemindBefore: 24 * time.Hour, Grace: 48 * time.Hour}
+	now := time.Date(2026, 9, 10, 8, 0, 0, 0, time.UTC)
+	remindedAt := func(ago time.Duration) *time.Time {
+		at := now.Add(-ago)
+		return &at
+	}
+
+	tests := []struct {
+		name        string
+		dueDate     time.Time
+		hasAssignee bool
+		reminderAt  *time.Time
+		want        []models.EscalationLevel
+	}{
+		{"срок завтра", now.Add(20 * time.Hour), true, nil, []models.EscalationLevel{models.EscalationDueSoon}},
+		{"срок завтра, исполнитель не назначен", now.Add(20 * time.Hour), false, nil, []models.EscalationLevel{models.EscalationDueSoon}},
+		{"срок истек только что", now, true, nil, []models.EscalationLevel{models.EscalationAssigneeReminder}},
+		{"просрочен, напоминания не было", now.Add(-72 * time.Hour), true, nil, []models.EscalationLevel{models.EscalationAssigneeReminder}},
+		{"просрочен без исполнителя", now.Add(-time.Hour), false, nil, []models.EscalationLevel{models.EscalationAssigneeReminder, models.EscalationManager}},
+		{"льготный период не истек", now.Add(-72 * time.Hour), true, remindedAt(47 * time.Hour), nil},
+		{"льготный период истек ровно сейчас", now.Add(-72 * time.Hour), true, remindedAt(48 * time.Hour), []models.EscalationLevel{models.EscalationManager}},
+		{"льготный период давно истек", now.Add(-240 * time.Hour), true, remindedAt(200 * time.Hour), []models.EscalationLevel{models.EscalationManager}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.escalationSteps(tt.dueDate, tt.hasAssignee, tt.reminderAt, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ступени %v, ожидались %v", got, tt.want)
+			}
+		})
+	}
+}
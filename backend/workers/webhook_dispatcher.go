@@ -0,0 +1,196 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"systemControl_proj/config"
+	"systemControl_proj/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// максимальный объем сохраняемого тела ответа получателя
+const maxWebhookResponseBody = 4096
+
+// фоновая доставка событий на зарегистрированные вебхуки
+type WebhookDispatcher struct {
+	DB           *gorm.DB
+	Client       *http.Client
+	PollInterval time.Duration
+	MaxAttempts  int
+	BatchSize    int
+	// базовая задержка повторной попытки, удваивается с каждой неудачей
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// на сколько захваченные доставки скрываются от других обработчиков
+	ClaimLease time.Duration
+}
+
+// создает обработчик доставок вебхуков по настройкам приложения
+func NewWebhookDispatcher(db *gorm.DB, cfg *config.Config) *WebhookDispatcher {
+	timeout := time.Duration(cfg.Webhook.TimeoutSeconds) * time.Second
+	d := &WebhookDispatcher{
+		DB:           db,
+		Client:       newWebhookClient(timeout, cfg.Webhook.AllowPrivateTargets),
+		PollInterval: time.Duration(cfg.Webhook.PollSeconds) * time.Second,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		BatchSize:    10,
+		RetryBase:    30 * time.Second,
+		RetryMax:     6 * time.Hour,
+	}
+	d.ClaimLease = time.Duration(d.BatchSize)*timeout + time.Minute
+	return d
+}
+
+// запускает периодическую обработку доставок до отмены контекста
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	log.Printf("Запуск доставки вебхуков (интервал %s)", d.PollInterval)
+
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := d.processBatch()
+			if err != nil {
+				log.Printf("Ошибка обработки доставок вебхуков: %v", err)
+				break
+			}
+			if processed < d.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// обрабатывает одну порцию доставок, готовых к отправке: доставки захватываются
+// коротким запросом, HTTP-запросы выполняются вне транзакции, а результат каждой
+// доставки сохраняется отдельно, чтобы один получатель не блокировал очередь
+func (d *WebhookDispatcher) processBatch() (int, error) {
+	var deliveries []models.WebhookDelivery
+	if err := claimBatch(d.DB, "webhook_deliveries", models.DeliveryStatusPending, d.BatchSize, d.ClaimLease, &deliveries); err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		if err := d.deliver(&deliveries[i]); err != nil {
+			// доставка вернется в очередь по истечении ClaimLease
+			log.Printf("Ошибка сохранения результата доставки %d: %v", deliveries[i].ID, err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// отправляет событие получателю и сохраняет результат попытки
+func (d *WebhookDispatcher) deliver(delivery *models.WebhookDelivery) error {
+	var webhook models.Webhook
+	err := d.DB.Unscoped().First(&webhook, delivery.WebhookID).Error
+	if err != nil {
+		return err
+	}
+
+	delivery.Attempts++
+	updates := map[string]interface{}{"attempts": delivery.Attempts}
+
+	// удаленные и отключенные вебхуки больше не получают событий
+	if webhook.DeletedAt.Valid || !webhook.Active {
+		updates["status"] = models.DeliveryStatusDead
+		updates["last_error"] = "вебхук отключен"
+		return d.saveAttempt(delivery, updates)
+	}
+
+	status, body, sendErr := d.send(&webhook, delivery)
+	updates = d.attemptUpdates(delivery.Attempts, sendErr, time.Now())
+	updates["response_status"] = status
+	updates["response_body"] = body
+	if updates["status"] == models.DeliveryStatusDead {
+		log.Printf("Доставка %d на %s не удалась после %d попыток: %v", delivery.ID, webhook.URL, delivery.Attempts, sendErr)
+	}
+
+	return d.saveAttempt(delivery, updates)
+}
+
+// изменения доставки по результату attempts-й попытки: доставлено,
+// исчерпан лимит попыток или повтор с увеличивающейся задержкой
+func (d *WebhookDispatcher) attemptUpdates(attempts int, sendErr error, now time.Time) map[string]interface{} {
+	updates := map[string]interface{}{"attempts": attempts}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.DeliveryStatusSuccess
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+	case attempts >= d.MaxAttempts:
+		updates["status"] = models.DeliveryStatusDead
+		updates["last_error"] = sanitizeResponseText(sendErr.Error())
+	default:
+		updates["next_attempt_at"] = now.Add(backoff(d.RetryBase, d.RetryMax, attempts))
+		updates["last_error"] = sanitizeResponseText(sendErr.Error())
+	}
+	return updates
+}
+
+// сохранение результата попытки доставки
+func (d *WebhookDispatcher) saveAttempt(delivery *models.WebhookDelivery, updates map[string]interface{}) error {
+	return d.DB.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error
+}
+
+// приведение текста ответа получателя к виду, который принимает столбец TEXT
+// в PostgreSQL: недопустимые последовательности UTF-8 заменяются, а нулевые
+// байты удаляются
+func sanitizeResponseText(text string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(text, "\uFFFD"), "\x00", "")
+}
+
+// выполняет подписанный POST-запрос; успешным считается любой ответ 2xx
+func (d *WebhookDispatcher) send(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "systemControl-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, sanitizeResponseText(string(body)), fmt.Errorf("получатель ответил статусом %d", resp.StatusCode)
+	}
+	return resp.StatusCode, sanitizeResponseText(string(body)), nil
+}
+
+// подпись HMAC-SHA256 строки "<timestamp>.<тело запроса>" секретом вебхука
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
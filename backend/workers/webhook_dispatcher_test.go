@@ -0,0 +1,96 @@
+package workers
+
+import (
+	"errors"
+	"strings"
+	"systemControl_proj/models"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"event":"defect.created","defect_id":42}`)
+	const want = "e14f8e11a2acab8baf0870832246cbe69fedd5be8fc0d7dd7e9653f2b1fd55ad"
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   []byte
+		same      bool
+	}{
+		{"те же данные", "whsec_test", "1767225600", payload, true},
+		{"другой секрет", "whsec_other", "1767225600", payload, false},
+		{"другая метка времени", "whsec_test", "1767225601", payload, false},
+		{"измененное тело", "whsec_test", "1767225600", []byte(`{"event":"defect.created","defect_id":43}`), false},
+		// разделитель не позволяет перенести цифры метки времени в начало тела
+		{"сдвиг границы метки времени", "whsec_test", "176722560", []byte("0." + string(payload)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SignWebhookPayload(tt.secret, tt.timestamp, tt.payload)
+			if (got == want) != tt.same {
+				t.Errorf("подпись %s, совпадение с эталоном %s ожидалось: %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestWebhookAttemptUpdates(t *testing.T) {
+	d := &WebhookDispatcher{MaxAttempts: 4, RetryBase: 30 * time.Second, RetryMax: 6 * time.Hour}
+	now := time.Date(2026, 7, 14, 3, 15, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		attempts  int
+		err       error
+		status    interface{}
+		nextAt    interface{}
+		lastError string
+	}{
+		{"ответ 2xx", 1, nil, models.DeliveryStatusSuccess, nil, ""},
+		{"ответ 500, повтор через 30 секунд", 1, errors.New("получатель ответил статусом 500"), nil, now.Add(30 * time.Second), "получатель ответил статусом 500"},
+		{"таймаут, третья попытка", 3, errors.New("context deadline exceeded"), nil, now.Add(2 * time.Minute), "context deadline exceeded"},
+		{"лимит попыток исчерпан", 4, errors.New("connection refused"), models.DeliveryStatusDead, nil, "connection refused"},
+		{"текст ошибки приводится к UTF-8", 2, errors.New("bad \xff\x00byte"), nil, now.Add(time.Minute), "bad �byte"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates := d.attemptUpdates(tt.attempts, tt.err, now)
+
+			if updates["status"] != tt.status {
+				t.Errorf("status = %v, ожидался %v", updates["status"], tt.status)
+			}
+			if updates["next_attempt_at"] != tt.nextAt {
+				t.Errorf("next_attempt_at = %v, ожидалось %v", updates["next_attempt_at"], tt.nextAt)
+			}
+			if updates["last_error"] != tt.lastError {
+				t.Errorf("last_error = %q, ожидалось %q", updates["last_error"], tt.lastError)
+			}
+			_, delivered := updates["delivered_at"]
+			if delivered != (tt.err == nil) {
+				t.Errorf("delivered_at задано: %v, ожидалось %v", delivered, tt.err == nil)
+			}
+		})
+	}
+}
+
+func TestSanitizeResponseText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{"ok":true}`, `{"ok":true}`},
+		{"принято", "принято"},
+		{"a\x00b\x00", "ab"},
+		{"\xc3\x28 ответ", "�( ответ"},
+		{strings.Repeat("\xff", 3), "�"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeResponseText(tt.in); got != tt.want {
+			t.Errorf("sanitizeResponseText(%q) = %q, ожидалось %q", tt.in, got, tt.want)
+		}
+	}
+}
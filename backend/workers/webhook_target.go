@@ -0,0 +1,90 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ошибка: адрес вебхука указывает на внутреннюю сеть (защита от SSRF)
+var ErrWebhookTargetBlocked = errors.New("адрес вебхука указывает на локальную или внутреннюю сеть")
+
+// общий диапазон адресов операторов (CGNAT), не покрываемый net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// адреса, на которые вебхуки не отправляются: loopback, частные сети,
+// link-local (в том числе метаданные облака 169.254.169.254) и служебные
+func blockedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// проверка адреса вебхука при регистрации: схема http/https и внешний адрес
+// узла; allowPrivate (WEBHOOK_ALLOW_PRIVATE_TARGETS) снимает ограничение сети
+func CheckWebhookURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("адрес вебхука должен быть URL со схемой http или https")
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedWebhookIP(ip) {
+			return ErrWebhookTargetBlocked
+		}
+		return nil
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return ErrWebhookTargetBlocked
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.New("не удалось определить адрес узла вебхука")
+	}
+	for _, addr := range addrs {
+		if blockedWebhookIP(addr.IP) {
+			return ErrWebhookTargetBlocked
+		}
+	}
+	return nil
+}
+
+// HTTP-клиент доставки вебхуков. Адрес проверяется при каждом соединении уже
+// после разрешения имени, поэтому запрет внутренней сети действует и для
+// перенаправлений и для имен, которые после регистрации стали указывать
+// на внутренние адреса
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedWebhookIP(ip) {
+				return ErrWebhookTargetBlocked
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// прокси не используется: соединение должно идти напрямую к проверенному адресу
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
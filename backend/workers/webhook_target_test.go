@@ -0,0 +1,102 @@
+package workers
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBlockedWebhookIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.10", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"224.0.0.1", true},
+		{"8.8.8.8", false},
+		{"100.128.0.1", false},
+		{"2001:4860:4860::8888", false},
+	}
+
+	for _, tt := range tests {
+		if got := blockedWebhookIP(net.ParseIP(tt.ip)); got != tt.blocked {
+			t.Errorf("blockedWebhookIP(%s) = %v, ожидалось %v", tt.ip, got, tt.blocked)
+		}
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		valid        bool
+		blocked      bool
+	}{
+		{"внешний IP", "https://203.0.113.10/hooks", false, true, false},
+		{"loopback", "http://127.0.0.1:8080/hook", false, false, true},
+		{"метаданные облака", "http://169.254.169.254/latest/meta-data", false, false, true},
+		{"localhost", "http://localhost/hook", false, false, true},
+		{"поддомен localhost", "http://api.Localhost/hook", false, false, true},
+		{"IPv6 loopback", "http://[::1]/hook", false, false, true},
+		{"внутренняя сеть разрешена", "http://10.0.0.5/hook", true, true, false},
+		{"схема ftp", "ftp://203.0.113.10/hook", false, false, false},
+		{"без узла", "https:///hook", false, false, false},
+		{"не URL", "://hook", true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWebhookURL(tt.url, tt.allowPrivate)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ошибка %v, ожидался допустимый адрес: %v", err, tt.valid)
+			}
+			if errors.Is(err, ErrWebhookTargetBlocked) != tt.blocked {
+				t.Errorf("ошибка %v, ожидался запрет внутренней сети: %v", err, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestWebhookClientBlocksInternalTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		allowPrivate bool
+		blocked      bool
+	}{
+		{"внутренняя сеть запрещена", false, true},
+		{"внутренняя сеть разрешена", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newWebhookClient(5*time.Second, tt.allowPrivate).Post(server.URL, "application/json", nil)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if errors.Is(err, ErrWebhookTargetBlocked) != tt.blocked {
+				t.Errorf("ошибка %v, ожидался запрет: %v", err, tt.blocked)
+			}
+			if !tt.blocked && err != nil {
+				t.Errorf("запрос не выполнен: %v", err)
+			}
+		})
+	}
+}
@@ -30,9 +30,154 @@ export interface User {
 export interface AuthResponse {
   message: string;
   token: string;
+  refresh_token: string;
+  expires_in: number;
   user: User;
 }
 
+// Один общий запрос обновления токенов на все параллельные запросы с истекшим токеном
+let refreshPromise: Promise<string | null> | null = null;
+
+/**
+ * Store tokens returned by /auth/login or /auth/refresh
+ */
+function storeTokens(data: AuthResponse): void {
+  localStorage.setItem('authToken', data.token);
+  localStorage.setItem('refreshToken', data.refresh_token);
+  localStorage.setItem('user', JSON.stringify(data.user));
+}
+
+/**
+ * Remove tokens and user data from localStorage
+ */
+function clearSession(): void {
+  localStorage.removeItem('authToken');
+  localStorage.removeItem('refreshToken');
+  localStorage.removeItem('user');
+}
+
+/**
+ * Exchange the refresh token for a new token pair.
+ * Returns the new access token or null if the session has ended.
+ */
+async function refreshTokens(): Promise<string | null> {
+  const refreshToken = localStorage.getItem('refreshToken');
+  if (!refreshToken) return null;
+
+  try {
+    const response = await fetch('/auth/refresh', {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json'
+      },
+      body: JSON.stringify({ refresh_token: refreshToken })
+    });
+    if (!response.ok) {
+      return null;
+    }
+    const data = (await response.json()) as AuthResponse;
+    storeTokens(data);
+    return data.token;
+  } catch (e) {
+    console.error('Ошибка при обновлении токена:', e);
+    return null;
+  }
+}
+
+/**
+ * fetch with the current access token. On 401 the token pair is refreshed
+ * once and the request is repeated; if the session has ended, the user
+ * is sent to the login page.
+ */
+export async function authFetch(input: string, init: RequestInit = {}): Promise<Response> {
+  const send = (token: string | null) => {
+    const headers = new Headers(init.headers);
+    if (token) {
+      headers.set('Authorization', `Bearer ${token}`);
+    }
+    return fetch(input, { ...init, headers });
+  };
+
+  const response = await send(localStorage.getItem('authToken'));
+  if (response.status !== 401 || !localStorage.getItem('refreshToken')) {
+    return response;
+  }
+
+  if (!refreshPromise) {
+    refreshPromise = refreshTokens().finally(() => {
+      refreshPromise = null;
+    });
+  }
+  const token = await refreshPromise;
+  if (!token) {
+    clearSession();
+    window.location.assign('/login');
+    return response;
+  }
+
+  return send(token);
+}
+
+// Метаданные постраничного ответа API
+export interface PageMeta {
+  total: number;
+  page: number;
+  per_page: number;
+  next_page: number | null;
+}
+
+/**
+ * GET one page of a list endpoint. Lists are always paginated on the server
+ * (20 items by default, at most 100 per page).
+ */
+export async function fetchListPage(
+  url: string,
+  params: Record<string, string | number>,
+  errorMessage: string
+): Promise<PageMeta & Record<string, unknown>> {
+  const query = new URLSearchParams();
+  for (const [key, value] of Object.entries(params)) {
+    query.set(key, String(value));
+  }
+  const separator = url.includes('?') ? '&' : '?';
+  const response = await authFetch(`${url}${separator}${query}`, {
+    method: 'GET',
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  });
+
+  let data;
+  try {
+    data = await response.json();
+  } catch (e) {
+    console.error('Ошибка при парсинге ответа:', e);
+    throw new Error('Ошибка соединения с сервером');
+  }
+
+  if (!response.ok) {
+    console.error(`${errorMessage}:`, data);
+    throw new Error(data?.error || `${errorMessage} (${response.status})`);
+  }
+
+  return data;
+}
+
+/**
+ * Load a whole list page by page following next_page. Only for screens
+ * that really need every item (dropdowns, project statistics).
+ */
+export async function fetchAllPages<T>(url: string, key: string, errorMessage: string): Promise<T[]> {
+  const items: T[] = [];
+  let page: number | null = 1;
+  while (page !== null) {
+    const data = await fetchListPage(url, { page, per_page: 100 }, errorMessage);
+    items.push(...((data[key] as T[] | undefined) || []));
+    page = data.next_page;
+  }
+  return items;
+}
+
 /**
  * Authentication service
  */
@@ -90,9 +235,8 @@ export const authService = {
       throw new Error(data?.error || `Неверное имя пользователя или пароль (${response.status})`);
     }
 
-    // Store token and user data in localStorage
-    localStorage.setItem('authToken', data.token);
-    localStorage.setItem('user', JSON.stringify(data.user));
+    // Store tokens and user data in localStorage
+    storeTokens(data);
 
     return data;
   },
@@ -101,8 +245,18 @@ export const authService = {
    * Logout the current user
    */
   logout(): void {
-    localStorage.removeItem('authToken');
-    localStorage.removeItem('user');
+    // Сессия отзывается на сервере, чтобы refresh-токен нельзя было использовать повторно
+    const refreshToken = localStorage.getItem('refreshToken');
+    if (refreshToken) {
+      fetch('/auth/logout', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json'
+        },
+        body: JSON.stringify({ refresh_token: refreshToken })
+      }).catch((e) => console.error('Ошибка при выходе из системы:', e));
+    }
+    clearSession();
   },
 
   /**
@@ -141,7 +295,7 @@ export const authService = {
     const token = this.getAuthToken();
     if (!token) throw new Error('Пользователь не авторизован');
 
-    const response = await fetch('/api/profile', {
+    const response = await authFetch('/api/profile', {
       method: 'GET',
       headers: {
         'Content-Type': 'application/json',
@@ -1,4 +1,4 @@
-import { authService } from './api';
+import { authService, authFetch, fetchAllPages } from './api';
 import type { User } from './userService';
 
 // Типы для работы с комментариями
@@ -28,28 +28,7 @@ export const commentService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/defects/${defectId}/comments`, {
-      method: 'GET',
-      headers: {
-        'Content-Type': 'application/json',
-        'Authorization': `Bearer ${token}`
-      }
-    });
-
-    let data;
-    try {
-      data = await response.json();
-    } catch (e) {
-      console.error('Ошибка при парсинге ответа:', e);
-      throw new Error('Ошибка соединения с сервером');
-    }
-
-    if (!response.ok) {
-      console.error('Ошибка получения комментариев:', data);
-      throw new Error(data?.error || `Ошибка получения комментариев (${response.status})`);
-    }
-
-    return data.comments || [];
+    return fetchAllPages<Comment>(`/api/defects/${defectId}/comments`, 'comments', 'Ошибка получения комментариев');
   },
 
   /**
@@ -61,7 +40,7 @@ export const commentService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch('/api/defects/comments', {
+    const response = await authFetch('/api/defects/comments', {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
@@ -95,7 +74,7 @@ export const commentService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/defects/comments/${commentId}`, {
+    const response = await authFetch(`/api/defects/comments/${commentId}`, {
       method: 'DELETE',
       headers: {
         'Content-Type': 'application/json',
@@ -1,4 +1,4 @@
-import { authService } from './api';
+import { authService, authFetch, fetchAllPages, fetchListPage } from './api';
 import type { User } from './userService';
 
 // Типы для работы с дефектами
@@ -42,6 +42,13 @@ export interface DefectUpdate {
   due_date?: string;
 }
 
+// Страница списка дефектов
+export interface DefectPage {
+  defects: Defect[];
+  total: number;
+  nextPage: number | null;
+}
+
 // Статистика дефектов для проекта
 export interface DefectStats {
   active: number; // Количество активных дефектов (new, in_progress, review)
@@ -60,28 +67,29 @@ export const defectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch('/api/defects', {
-      method: 'GET',
-      headers: {
-        'Content-Type': 'application/json',
-        'Authorization': `Bearer ${token}`
-      }
-    });
+    return fetchAllPages<Defect>('/api/defects', 'defects', 'Ошибка получения дефектов');
+  },
 
-    let data;
-    try {
-      data = await response.json();
-    } catch (e) {
-      console.error('Ошибка при парсинге ответа:', e);
-      throw new Error('Ошибка соединения с сервером');
+  /**
+   * Получение одной страницы дефектов (по умолчанию 20 записей),
+   * при необходимости с фильтром по статусу
+   */
+  async getDefectsPage(page: number, status?: string): Promise<DefectPage> {
+    const token = authService.getAuthToken();
+    if (!token) {
+      throw new Error('Пользователь не авторизован');
     }
 
-    if (!response.ok) {
-      console.error('Ошибка получения дефектов:', data);
-      throw new Error(data?.error || `Ошибка получения дефектов (${response.status})`);
+    const params: Record<string, string | number> = { page };
+    if (status) {
+      params.status = status;
     }
-
-    return data.defects;
+    const data = await fetchListPage('/api/defects', params, 'Ошибка получения дефектов');
+    return {
+      defects: (data.defects as Defect[] | undefined) || [],
+      total: data.total,
+      nextPage: data.next_page
+    };
   },
 
   /**
@@ -93,28 +101,7 @@ export const defectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/projects/${projectId}/defects`, {
-      method: 'GET',
-      headers: {
-        'Content-Type': 'application/json',
-        'Authorization': `Bearer ${token}`
-      }
-    });
-
-    let data;
-    try {
-      data = await response.json();
-    } catch (e) {
-      console.error('Ошибка при парсинге ответа:', e);
-      throw new Error('Ошибка соединения с сервером');
-    }
-
-    if (!response.ok) {
-      console.error('Ошибка получения дефектов:', data);
-      throw new Error(data?.error || `Ошибка получения дефектов (${response.status})`);
-    }
-
-    return data.defects;
+    return fetchAllPages<Defect>(`/api/projects/${projectId}/defects`, 'defects', 'Ошибка получения дефектов');
   },
 
   /**
@@ -126,7 +113,7 @@ export const defectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/defects/${defectId}`, {
+    const response = await authFetch(`/api/defects/${defectId}`, {
       method: 'GET',
       headers: {
         'Content-Type': 'application/json',
@@ -166,7 +153,7 @@ export const defectService = {
       formattedDefect.due_date = date.toISOString();
     }
 
-    const response = await fetch('/api/defects', {
+    const response = await authFetch('/api/defects', {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
@@ -207,7 +194,7 @@ export const defectService = {
       formattedDefect.due_date = date.toISOString();
     }
 
-    const response = await fetch(`/api/defects/${defectId}`, {
+    const response = await authFetch(`/api/defects/${defectId}`, {
       method: 'PUT',
       headers: {
         'Content-Type': 'application/json',
@@ -241,7 +228,7 @@ export const defectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/defects/${defectId}`, {
+    const response = await authFetch(`/api/defects/${defectId}`, {
       method: 'DELETE',
       headers: {
         'Content-Type': 'application/json',
@@ -271,7 +258,7 @@ export const defectService = {
     }
 
     try {
-      const response = await fetch(`/api/projects/${projectId}/defects/stats`, {
+      const response = await authFetch(`/api/projects/${projectId}/defects/stats`, {
         method: 'GET',
         headers: {
           'Content-Type': 'application/json',
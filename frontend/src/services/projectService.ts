@@ -1,4 +1,4 @@
-import { authService } from './api';
+import { authService, authFetch, fetchAllPages } from './api';
 
 // Типы для работы с проектами
 export interface Project {
@@ -51,28 +51,7 @@ export const projectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch('/api/projects', {
-      method: 'GET',
-      headers: {
-        'Content-Type': 'application/json',
-        'Authorization': `Bearer ${token}`
-      }
-    });
-
-    let data;
-    try {
-      data = await response.json();
-    } catch (e) {
-      console.error('Ошибка при парсинге ответа:', e);
-      throw new Error('Ошибка соединения с сервером');
-    }
-
-    if (!response.ok) {
-      console.error('Ошибка получения проектов:', data);
-      throw new Error(data?.error || `Ошибка получения проектов (${response.status})`);
-    }
-
-    return data.projects;
+    return fetchAllPages<Project>('/api/projects', 'projects', 'Ошибка получения проектов');
   },
 
   /**
@@ -84,7 +63,7 @@ export const projectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/projects/${id}`, {
+    const response = await authFetch(`/api/projects/${id}`, {
       method: 'GET',
       headers: {
         'Content-Type': 'application/json',
@@ -117,7 +96,7 @@ export const projectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch('/api/projects', {
+    const response = await authFetch('/api/projects', {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
@@ -151,7 +130,7 @@ export const projectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/projects/${id}`, {
+    const response = await authFetch(`/api/projects/${id}`, {
       method: 'PUT',
       headers: {
         'Content-Type': 'application/json',
@@ -185,7 +164,7 @@ export const projectService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/projects/${id}`, {
+    const response = await authFetch(`/api/projects/${id}`, {
       method: 'DELETE',
       headers: {
         'Content-Type': 'application/json',
@@ -1,4 +1,4 @@
-import { authService } from './api';
+import { authService, authFetch, fetchAllPages } from './api';
 
 // Тип для пользователя
 export interface User {
@@ -27,28 +27,7 @@ export const userService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch('/api/users', {
-      method: 'GET',
-      headers: {
-        'Content-Type': 'application/json',
-        'Authorization': `Bearer ${token}`
-      }
-    });
-
-    let data;
-    try {
-      data = await response.json();
-    } catch (e) {
-      console.error('Ошибка при парсинге ответа:', e);
-      throw new Error('Ошибка соединения с сервером');
-    }
-
-    if (!response.ok) {
-      console.error('Ошибка получения пользователей:', data);
-      throw new Error(data?.error || `Ошибка получения пользователей (${response.status})`);
-    }
-
-    return data.users;
+    return fetchAllPages<User>('/api/users', 'users', 'Ошибка получения пользователей');
   },
 
   /**
@@ -61,7 +40,7 @@ export const userService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch('/api/users/engineers', {
+    const response = await authFetch('/api/users/engineers', {
       method: 'GET',
       headers: {
         'Content-Type': 'application/json',
@@ -95,7 +74,7 @@ export const userService = {
       throw new Error('Пользователь не авторизован');
     }
 
-    const response = await fetch(`/api/users/${userId}/role`, {
+    const response = await authFetch(`/api/users/${userId}/role`, {
       method: 'PUT',
       headers: {
         'Content-Type': 'application/json',